@@ -38,7 +38,9 @@ func main() {
 	g.Transform(clean)
 	g.Group(user)
 	g.Window(window.Fixed(1 * time.Hour))
-	g.Trigger(trigger.WhenFinished())
+	g.Trigger(trigger.Fresh(func() trigger.Trigger {
+		return trigger.WhenFinished()
+	}))
 	g.Into(sink.SkipSetup(funcsink.New(print)))
 
 	// Register our message type, and graph type.
@@ -37,7 +37,9 @@ func main() {
 	g.From(source.SkipSetup(jsonfile.New(Entry{}, "event.data")))
 	g.Transform(clean)
 	g.Window(window.Sliding(1*time.Hour, 2*time.Minute))
-	g.Trigger(trigger.AtPeriod(5 * time.Second))
+	g.Trigger(trigger.Fresh(func() trigger.Trigger {
+		return trigger.AtPeriod(5 * time.Second)
+	}))
 	g.Into(sink.SkipSetup(funcsink.New(print)))
 
 	// Register our message type, and graph type.
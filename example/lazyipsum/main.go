@@ -32,7 +32,9 @@ func main() {
 	g.Transform(clean)
 	g.Group(word)
 	g.Merger(adder)
-	g.Trigger(trigger.WhenDormant(2 * time.Second))
+	g.Trigger(trigger.Fresh(func() trigger.Trigger {
+		return trigger.WhenDormant(2 * time.Second)
+	}))
 	g.Into(sink.SkipSetup(funcsink.New(print)))
 
 	// Register our message type, and graph type.
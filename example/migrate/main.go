@@ -0,0 +1,118 @@
+// Command migrate moves the windows of a graph's database
+// from one storage driver to another, or exports them to a
+// snapshot file and imports them back, for example:
+//
+//	migrate -name worker-host1-wordcount-a -from bolt -from-dir /var/flo -to leveldb -to-dir /var/flo2
+//	migrate -name worker-host1-wordcount-a -from bolt -from-dir /var/flo -export wordcount.snapshot
+//	migrate -name worker-host1-wordcount-a -to leveldb -to-dir /var/flo2 -import wordcount.snapshot
+//
+// Each worker keeps a database per graph, named after its
+// process, and the graph must not be running. Values are
+// decoded while moved, so the message types stored by the
+// graph must be registered, see main.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/lytics/flo/storage"
+	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/storage/driver/badgerdriver"
+	"github.com/lytics/flo/storage/driver/boltdriver"
+	"github.com/lytics/flo/storage/driver/leveldbdriver"
+)
+
+func main() {
+	name := flag.String("name", "", "name of the database, which is the process's: worker-<peer>-<graph type>-<graph name>")
+	from := flag.String("from", "", "driver to migrate from: bolt, leveldb or badger")
+	fromDir := flag.String("from-dir", "", "base directory of the driver to migrate from")
+	to := flag.String("to", "", "driver to migrate to: bolt, leveldb or badger")
+	toDir := flag.String("to-dir", "", "base directory of the driver to migrate to")
+	export := flag.String("export", "", "export the database to this snapshot file, rather than migrate")
+	imp := flag.String("import", "", "import the database from this snapshot file, rather than migrate")
+	flag.Parse()
+
+	if *name == "" {
+		successOrDie(fmt.Errorf("missing name of the database"))
+	}
+
+	// Register the message types stored by the graphs
+	// here, for example:
+	//
+	//	flo.RegisterMsg(Word{})
+
+	ctx := context.Background()
+	switch {
+	case *export != "":
+		successOrDie(exportTo(ctx, *name, cfgOf(*from, *fromDir), *export))
+	case *imp != "":
+		successOrDie(importFrom(ctx, *name, cfgOf(*to, *toDir), *imp))
+	default:
+		successOrDie(storage.Migrate(ctx, *name, cfgOf(*from, *fromDir), cfgOf(*to, *toDir)))
+	}
+}
+
+// exportTo the snapshot file the database of the driver.
+func exportTo(ctx context.Context, name string, cfg driver.Cfg, file string) error {
+	db, err := storage.Open(name, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	f, err := os.Create(file)
+	if err != nil {
+		return err
+	}
+	err = db.Export(ctx, f)
+	if err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// importFrom the snapshot file into the database of the
+// driver.
+func importFrom(ctx context.Context, name string, cfg driver.Cfg, file string) error {
+	db, err := storage.Open(name, cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return db.Import(ctx, f)
+}
+
+// cfgOf the named driver, in the base directory.
+func cfgOf(driverName, dir string) driver.Cfg {
+	if dir == "" {
+		successOrDie(fmt.Errorf("missing base directory of driver: %v", driverName))
+	}
+	switch driverName {
+	case boltdriver.DriverName:
+		return boltdriver.Cfg{BaseDir: dir}
+	case leveldbdriver.DriverName:
+		return leveldbdriver.Cfg{BaseDir: dir}
+	case badgerdriver.DriverName:
+		return badgerdriver.Cfg{BaseDir: dir}
+	}
+	successOrDie(fmt.Errorf("unknown driver: %q", driverName))
+	return nil
+}
+
+func successOrDie(err error) {
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		os.Exit(1)
+	}
+}
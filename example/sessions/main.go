@@ -34,7 +34,9 @@ func main() {
 	g.Transform(clean)
 	g.Group(user)
 	g.Window(window.Session(30 * time.Minute))
-	g.Trigger(trigger.AtPeriod(10 * time.Second))
+	g.Trigger(trigger.Fresh(func() trigger.Trigger {
+		return trigger.AtPeriod(10 * time.Second)
+	}))
 	g.Into(sink.SkipSetup(funcsink.New(print)))
 
 	// Register our message type, and graph type.
@@ -0,0 +1,97 @@
+package graph
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lytics/flo/sink"
+)
+
+// Failure handling of fires which keep failing, after
+// their retries.
+type Failure int
+
+const (
+	// Fail the graph, which is the default.
+	Fail Failure = 0
+	// RetryForever at the max backoff, the fire
+	// is never given up.
+	RetryForever Failure = 1
+	// DeadLetter gives the windows of the fire to
+	// the dead-letter sinks, and moves on.
+	DeadLetter Failure = 2
+)
+
+func (f Failure) String() string {
+	switch f {
+	case Fail:
+		return "fail"
+	case RetryForever:
+		return "retry forever"
+	case DeadLetter:
+		return "dead letter"
+	default:
+		return fmt.Sprintf("Failure(%d)", int(f))
+	}
+}
+
+// FirePolicy of emitting the keys fired by the trigger.
+// Zero fields take their defaults.
+type FirePolicy struct {
+	// Pending fires queued, once full the trigger
+	// blocks until sinks catch up. Default 10000.
+	Pending int
+	// Retries of a failed fire, before the failure
+	// is handled. Default 3, negative is none.
+	Retries int
+	// Backoff before the first retry, doubled for
+	// each retry up to MaxBackoff. Default one
+	// second and one minute.
+	Backoff    time.Duration
+	MaxBackoff time.Duration
+	// OnFailure of a fire, after its retries.
+	OnFailure Failure
+	// DeadLetter sinks, required by the DeadLetter
+	// failure handling.
+	DeadLetter sink.Sinks
+}
+
+// Delay before the given retry, starting from one.
+func (p FirePolicy) Delay(retry int) time.Duration {
+	d := p.Backoff
+	for i := 1; i < retry && d < p.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > p.MaxBackoff {
+		return p.MaxBackoff
+	}
+	return d
+}
+
+// Fire defines how the keys fired by the trigger are queued
+// and retried, and what happens when they keep failing.
+func (g *Graph) Fire(p FirePolicy) {
+	g.fire = p
+}
+
+// Fire definition, in other words, how fires are queued and
+// retried, with defaults for unset fields.
+func (def *Definition) Fire() FirePolicy {
+	p := def.g.fire
+	if p.Pending <= 0 {
+		p.Pending = 10000
+	}
+	if p.Retries == 0 {
+		p.Retries = 3
+	}
+	if p.Retries < 0 {
+		p.Retries = 0
+	}
+	if p.Backoff <= 0 {
+		p.Backoff = 1 * time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 1 * time.Minute
+	}
+	return p
+}
@@ -0,0 +1,50 @@
+package graph
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFirePolicyDelay(t *testing.T) {
+	p := FirePolicy{Backoff: 1 * time.Second, MaxBackoff: 5 * time.Second}
+
+	// Doubled for each retry, up to the max.
+	expected := []time.Duration{1 * time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second}
+	for i, d := range expected {
+		if found := p.Delay(i + 1); found != d {
+			t.Fatalf("expected retry: %v delay: %v, found: %v", i+1, d, found)
+		}
+	}
+
+	// A backoff over the max is capped.
+	p = FirePolicy{Backoff: 10 * time.Second, MaxBackoff: 5 * time.Second}
+	if found := p.Delay(1); found != 5*time.Second {
+		t.Fatalf("expected capped delay, found: %v", found)
+	}
+}
+
+func TestFireDefaults(t *testing.T) {
+	g := New()
+	p := g.Definition().Fire()
+	if p.Pending != 10000 || p.Retries != 3 || p.Backoff != 1*time.Second || p.MaxBackoff != 1*time.Minute || p.OnFailure != Fail {
+		t.Fatalf("expected defaults, found: %+v", p)
+	}
+
+	g.Fire(FirePolicy{Pending: 10, Retries: -1, Backoff: 2 * time.Second, OnFailure: RetryForever})
+	p = g.Definition().Fire()
+	if p.Pending != 10 || p.Retries != 0 || p.Backoff != 2*time.Second || p.MaxBackoff != 1*time.Minute || p.OnFailure != RetryForever {
+		t.Fatalf("expected defined policy, found: %+v", p)
+	}
+}
+
+func TestFailureString(t *testing.T) {
+	if s := DeadLetter.String(); s != "dead letter" {
+		t.Fatalf("expected dead letter, found: %v", s)
+	}
+	// Unknown failures, such as of a decoded checkpoint,
+	// are described rather than panicking.
+	if s := fmt.Sprintf("%v", Failure(7)); s != "Failure(7)" {
+		t.Fatalf("expected unknown failure, found: %v", s)
+	}
+}
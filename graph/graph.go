@@ -29,9 +29,14 @@ type Graph struct {
 	transform func(interface{}) ([]Event, error)
 	group     func(interface{}) (string, error)
 	merger    merger.Merger
+	mergers   merger.Mergers
 	window    window.Window
-	trigger   trigger.Trigger
+	windows   window.Windows
+	trigger   trigger.Triggers
 	into      sink.Sinks
+	retention time.Duration
+	disorder  time.Duration
+	fire      FirePolicy
 }
 
 // From defines the sources of data.
@@ -50,20 +55,40 @@ func (g *Graph) Group(f func(interface{}) (string, error)) {
 }
 
 // Window defines how to calculate which windows of time
-// an event belongs to.
+// an event belongs to. The window is shared by every
+// instance of the graph, so it must keep no state of
+// its own, see WindowSetup otherwise.
 func (g *Graph) Window(w window.Window) {
 	g.window = w
+	g.windows = nil
+}
+
+// WindowSetup defines how to set up the window of each
+// instance of the graph, for windows which keep state or
+// are configured by the instance's conf.
+func (g *Graph) WindowSetup(ws window.Windows) {
+	g.windows = ws
 }
 
 // Merger defines the function to use for merging events
-// mapped to the same key.
+// mapped to the same key. Like the window it is shared
+// by every instance of the graph, see MergerSetup.
 func (g *Graph) Merger(f merger.Merger) {
 	g.merger = f
+	g.mergers = nil
+}
+
+// MergerSetup defines how to set up the merger of each
+// instance of the graph, for mergers which keep state or
+// are configured by the instance's conf.
+func (g *Graph) MergerSetup(ms merger.Mergers) {
+	g.mergers = ms
 }
 
 // Trigger defines how to translate event-time events into
-// process-time events.
-func (g *Graph) Trigger(t trigger.Trigger) {
+// process-time events. Each instance of the graph sets up
+// its own trigger, since triggers keep state.
+func (g *Graph) Trigger(t trigger.Triggers) {
 	g.trigger = t
 }
 
@@ -72,6 +97,26 @@ func (g *Graph) Into(ss sink.Sinks) {
 	g.into = ss
 }
 
+// Retention defines how long windows are kept in storage,
+// measured in event time. A window is expired when its end
+// is older than the watermark minus keep. Expired windows
+// are garbage collected and late events destined for them
+// are dropped. The default of zero keeps windows forever.
+func (g *Graph) Retention(keep time.Duration) {
+	g.retention = keep
+}
+
+// OutOfOrder defines how far behind the latest event time
+// of a mapper its events may still arrive, measured in
+// event time. The watermark is the progress of the mapper
+// furthest behind, where the progress of a mapper is its
+// latest event time minus d, so events more out of order
+// than d are late. The default of zero expects the events
+// of each mapper in order.
+func (g *Graph) OutOfOrder(d time.Duration) {
+	g.disorder = d
+}
+
 // Definition of the graph, which can be called
 // after From, Transform, Group, Window, Merger
 // Trigger, and Into have been set.
@@ -84,6 +129,28 @@ type Definition struct {
 	g *Graph
 }
 
+// Setup the definition of one instance of the graph, with
+// its own window and merger when they are set up per
+// instance. Other definitions are shared.
+func (def *Definition) Setup(graphType, graphName string, conf []byte) (*Definition, error) {
+	g := *def.g
+	if g.windows != nil {
+		w, err := g.windows.Setup(graphType, graphName, conf)
+		if err != nil {
+			return nil, err
+		}
+		g.window = w
+	}
+	if g.mergers != nil {
+		m, err := g.mergers.Setup(graphType, graphName, conf)
+		if err != nil {
+			return nil, err
+		}
+		g.merger = m
+	}
+	return &Definition{&g}, nil
+}
+
 // From definition, in other words, were to source data.
 func (def *Definition) From() source.Sources {
 	return def.g.from
@@ -106,7 +173,7 @@ func (def *Definition) GroupAndWindowBy(e Event) ([]Event, error) {
 	var events []Event
 
 	// Calculate window spans.
-	windows := def.g.window.Apply(e.Time)
+	windows := def.g.window.Apply(e.Time, e.Data)
 
 	// When user defined group function exists,
 	// use it to group items by generating a
@@ -147,11 +214,114 @@ func (def *Definition) Merge(w window.Span, v interface{}, prev window.State) er
 	return def.g.window.Merge(w, v, prev, f)
 }
 
-// Trigger definition.
-func (def *Definition) Trigger() trigger.Trigger {
+// Assemble the windows to emit from the stored windows
+// of a key, which are emitted as is unless the window
+// is an assembler.
+func (def *Definition) Assemble(stored map[window.Span][]interface{}) (map[window.Span][]interface{}, error) {
+	a, ok := def.g.window.(window.Assembler)
+	if !ok {
+		return stored, nil
+	}
+	f := merger.Cons()
+	if def.g.merger != nil {
+		f = merger.Fold(def.g.merger)
+	}
+	return a.Assemble(stored, f)
+}
+
+// Windows to emit which hold the stored span s, read from
+// the state of a key. Unless the window is a holder or an
+// assembler these are the stored windows overlapping s. Otherwise
+// the windows are only assembled when values is true,
+// else each is given the values of s, so the panes of a
+// window are merged when it is emitted, not per event.
+func (def *Definition) Windows(s window.Span, state window.State, values bool) (map[window.Span][]interface{}, error) {
+	if h, ok := def.g.window.(window.Holder); ok {
+		return h.Holding(s, state), nil
+	}
+	a, ok := def.g.window.(window.Assembler)
+	if !ok {
+		return state.Overlapping(s), nil
+	}
+	covering := a.Covering(s)
+	if len(covering) == 0 {
+		return nil, nil
+	}
+	if !values {
+		vs := state.Get(s)
+		held := make(map[window.Span][]interface{}, len(covering))
+		for _, c := range covering {
+			held[c] = vs
+		}
+		return held, nil
+	}
+	// Every stored span of the covering windows is read,
+	// so they are assembled with all of their values.
+	cover := covering[0]
+	for _, c := range covering[1:] {
+		cover = cover.Expand(c)
+	}
+	ws, err := def.Assemble(state.Overlapping(cover))
+	if err != nil {
+		return nil, err
+	}
+	held := map[window.Span][]interface{}{}
+	for _, c := range covering {
+		if vs, ok := ws[c]; ok {
+			held[c] = vs
+		}
+	}
+	return held, nil
+}
+
+// Reset the state of a key, by the window's own reset
+// when it has one, otherwise by deleting every window.
+func (def *Definition) Reset(prev window.State) {
+	if r, ok := def.g.window.(window.Resetter); ok {
+		r.Reset(prev)
+		return
+	}
+	for s := range prev.Windows() {
+		prev.Del(s)
+	}
+}
+
+// Restore windows taken from the state of a key, such as
+// by a discarding fire which failed. Values merged into a
+// window since it was taken are merged after its values.
+func (def *Definition) Restore(taken map[window.Span][]interface{}, state window.State) error {
+	f := merger.Cons()
+	if def.g.merger != nil {
+		f = merger.Fold(def.g.merger)
+	}
+	for s, vs := range taken {
+		merged, err := f(state.Get(s), vs)
+		if err != nil {
+			return err
+		}
+		state.Set(s, merged)
+	}
+	return nil
+}
+
+// Trigger definition, in other words, how to set up the
+// trigger of each graph instance.
+func (def *Definition) Trigger() trigger.Triggers {
 	return def.g.trigger
 }
 
+// Retention definition, in other words, how long windows are
+// kept after the watermark passes their end. Zero means forever.
+func (def *Definition) Retention() time.Duration {
+	return def.g.retention
+}
+
+// OutOfOrder definition, in other words, how far behind
+// the latest event time of a mapper events may arrive.
+func (def *Definition) OutOfOrder() time.Duration {
+	return def.g.disorder
+}
+
 // Into definition, in other words, were to sink data.
 func (def *Definition) Into() sink.Sinks {
 	return def.g.into
@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/merger"
+	"github.com/lytics/flo/window"
+	"github.com/lytics/flo/window/windowtest"
+)
+
+func TestWindowsOfPanes(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	g := New()
+	g.Window(window.Sliding(2*time.Minute, 1*time.Minute))
+	def := g.Definition()
+
+	state := windowtest.NewState()
+	for i, ts := range []time.Time{t0, t0.Add(1 * time.Minute), t0.Add(1 * time.Minute)} {
+		pane := def.g.window.Apply(ts, i)[0]
+		if err := def.Merge(pane, i, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	pane := window.NewSpan(t0.Add(1*time.Minute), t0.Add(2*time.Minute))
+	covering := []window.Span{
+		window.NewSpan(t0, t0.Add(2*time.Minute)),
+		window.NewSpan(t0.Add(1*time.Minute), t0.Add(3*time.Minute)),
+	}
+
+	// Without values the covering windows are given
+	// the values of the pane, nothing is assembled.
+	ws, err := def.Windows(pane, state, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws) != 2 || len(ws[covering[0]]) != 2 || len(ws[covering[1]]) != 2 {
+		t.Fatalf("expected covering windows with values of the pane, found: %v", ws)
+	}
+
+	// With values they are assembled from every pane.
+	ws, err = def.Windows(pane, state, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ws) != 2 || len(ws[covering[0]]) != 3 || len(ws[covering[1]]) != 2 {
+		t.Fatalf("expected assembled covering windows, found: %v", ws)
+	}
+}
+
+func TestSetupPerInstance(t *testing.T) {
+	g := New()
+	g.WindowSetup(window.SetupFunc(func(graphType, graphName string, conf []byte) (window.Window, error) {
+		return window.Fixed(time.Duration(len(conf)) * time.Minute), nil
+	}))
+	g.MergerSetup(merger.SetupFunc(func(graphType, graphName string, conf []byte) (merger.Merger, error) {
+		return func(a, b interface{}) (interface{}, error) {
+			return graphName, nil
+		}, nil
+	}))
+
+	// Each instance has its own window and merger,
+	// set up from its name and conf.
+	a, err := g.Definition().Setup("type", "a", []byte("x"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := g.Definition().Setup("type", "b", []byte("xx"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	for def, d := range map[*Definition]time.Duration{a: 1 * time.Minute, b: 2 * time.Minute} {
+		es, err := def.GroupAndWindowBy(Event{Time: t0, Data: 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(es) != 1 || es[0].Window.Duration() != d {
+			t.Fatalf("expected window of: %v, found: %v", d, es)
+		}
+	}
+
+	state := windowtest.NewState()
+	s := window.NewSpan(t0, t0.Add(1*time.Minute))
+	for _, v := range []int{1, 2} {
+		if err := a.Merge(s, v, state); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if vs := state.Get(s); len(vs) != 1 || vs[0] != "a" {
+		t.Fatalf("expected merger of instance a, found: %v", vs)
+	}
+}
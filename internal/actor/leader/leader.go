@@ -5,11 +5,14 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/lytics/flo/graph"
 	"github.com/lytics/flo/internal/msg"
 	"github.com/lytics/flo/internal/peerqueue"
+	"github.com/lytics/flo/internal/process/mapred"
 	"github.com/lytics/flo/internal/registry"
 	"github.com/lytics/flo/storage"
 	"github.com/lytics/grid"
@@ -28,26 +31,37 @@ type Send func(timeout time.Duration, receiver string, msg interface{}) (interfa
 
 type Listen func(name string) (<-chan grid.Request, func() error, error)
 
+type SetActual func(ctx context.Context, graphType, graphName string, state registry.State) error
+
 // New peer watcher.
-func New(d Define, s Send, l Listen, w Watch, p Peers, m Mailboxes) (*Actor, error) {
+func New(d Define, s Send, l Listen, w Watch, p Peers, m Mailboxes, sa SetActual) (*Actor, error) {
 	return &Actor{
 		logger:    log.New(os.Stderr, "leader: ", log.LstdFlags),
 		tracker:   peerqueue.New(),
+		done:      map[string]map[string]bool{},
+		ending:    map[string]bool{},
 		define:    d,
 		send:      s,
 		listen:    l,
 		watch:     w,
 		peers:     p,
 		mailboxes: m,
+		setActual: sa,
 	}, nil
 }
 
 type Actor struct {
+	mu      sync.Mutex
 	eg      *errgroup.Group
 	ctx     context.Context
 	name    string
 	logger  *log.Logger
 	tracker *peerqueue.PeerQueue
+	// Workers of the term, mappers done per
+	// graph, and graphs being sent end of stream.
+	term   []string
+	done   map[string]map[string]bool
+	ending map[string]bool
 	// Outside world
 	db        *storage.DB
 	define    Define
@@ -56,6 +70,7 @@ type Actor struct {
 	mailboxes Mailboxes
 	send      Send
 	listen    Listen
+	setActual SetActual
 }
 
 // String description of the actor.
@@ -193,6 +208,7 @@ func (a *Actor) runTermWatcher() error {
 			break
 		}
 	}
+	a.setTerm(term)
 
 	events, close, err := a.listen(a.name)
 	if err != nil {
@@ -205,7 +221,144 @@ func (a *Actor) runTermWatcher() error {
 		case <-a.ctx.Done():
 			return nil
 		case req := <-events:
-			req.Respond(&msg.Term{Peers: term})
+			switch m := req.Msg().(type) {
+			case *msg.Progress:
+				a.progress(m)
+				req.Ack()
+			case *msg.ResetKeys:
+				a.eg.Go(func() error {
+					err := a.reset(m)
+					if err != nil {
+						req.Respond(err)
+					} else {
+						req.Ack()
+					}
+					return nil
+				})
+			default:
+				req.Respond(&msg.Term{Peers: term})
+			}
+		}
+	}
+}
+
+// setTerm of the leader, kept as the sorted names of its
+// workers, which are the parents of the graph mappers.
+func (a *Actor) setTerm(term []string) {
+	workers := make([]string, 0, len(term))
+	for _, peer := range term {
+		workers = append(workers, workerDef(peer).Name)
+	}
+	sort.Strings(workers)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.term = workers
+}
+
+// progress of a graph's mapper. Once the mapper of every
+// worker in the term is done, the graph has reached the
+// end of its stream. Mappers repeat their report until
+// the end of stream, so a restarted leader learns of them
+// again, but reports of another term, or of mappers
+// outside the term, are ignored, since their shuffles
+// went to other reducers.
+func (a *Actor) progress(m *msg.Progress) {
+	if !m.Done {
+		return
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !sameTerm(a.term, m.Term) || !inTerm(a.term, m.Peer) {
+		a.logger.Printf("term watcher: graph: %v, ignoring mapper done: %v, of term: %v", m.Graph, m.Peer, m.Term)
+		return
+	}
+	if a.ending[m.Graph] {
+		return
+	}
+	done, ok := a.done[m.Graph]
+	if !ok {
+		done = map[string]bool{}
+		a.done[m.Graph] = done
+	}
+	done[m.Peer] = true
+	a.logger.Printf("term watcher: graph: %v, mapper done: %v, sources: %v", m.Graph, m.Peer, m.Source)
+
+	for _, worker := range a.term {
+		if !done[worker] {
+			return
+		}
+	}
+	delete(a.done, m.Graph)
+	a.ending[m.Graph] = true
+
+	term := a.term
+	key, graphType, graphName := m.Graph, m.GraphType, m.GraphName
+	a.eg.Go(func() error {
+		a.endOfStream(term, key, graphType, graphName)
+		return nil
+	})
+}
+
+// endOfStream of the graph is sent to the process on every
+// worker in the term. Each process acks after its trigger
+// has handled the end of stream, so once all have acked
+// the graph is completed.
+func (a *Actor) endOfStream(term []string, key, graphType, graphName string) {
+	defer func() {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+		delete(a.ending, key)
+	}()
+
+	for _, worker := range term {
+		receiver := mapred.Name(worker, graphType, graphName)
+		err := a.retry(func() error {
+			_, err := a.send(30*time.Second, receiver, &msg.Progress{Graph: key, GraphType: graphType, GraphName: graphName, Done: true})
+			return err
+		})
+		if err != nil {
+			return
+		}
+	}
+
+	err := a.retry(func() error {
+		return a.setActual(a.ctx, graphType, graphName, registry.Completed)
+	})
+	if err != nil {
+		return
+	}
+	a.logger.Printf("end of stream: graph: %v, completed", key)
+}
+
+// reset the keys of the graph, on the process of every
+// live worker, since only the reducer of a key holds its
+// windows.
+func (a *Actor) reset(m *msg.ResetKeys) error {
+	for peer := range a.tracker.Peers() {
+		receiver := mapred.Name(workerDef(peer).Name, m.GraphType, m.GraphName)
+		_, err := a.send(30*time.Second, receiver, m)
+		if err != nil {
+			return fmt.Errorf("failed resetting keys on: %v, error: %v", receiver, err)
+		}
+	}
+	return nil
+}
+
+// retry f until it succeeds or the actor exits.
+func (a *Actor) retry(f func() error) error {
+	for {
+		err := f()
+		if err == nil {
+			return nil
+		}
+		a.logger.Printf("end of stream: failed: %v", err)
+		select {
+		case <-a.ctx.Done():
+			return a.ctx.Err()
+		case <-time.After(10 * time.Second):
 		}
 	}
 }
@@ -231,6 +384,34 @@ func workerDef(peer string) *grid.ActorStart {
 	return def
 }
 
+// sameTerm when the workers are those of the term, which
+// is never the case for an empty term.
+func sameTerm(term, workers []string) bool {
+	seen := map[string]bool{}
+	for _, w := range workers {
+		seen[w] = true
+	}
+	if len(term) == 0 || len(seen) != len(term) {
+		return false
+	}
+	for _, w := range term {
+		if !seen[w] {
+			return false
+		}
+	}
+	return true
+}
+
+// inTerm when the worker is one of the term.
+func inTerm(term []string, worker string) bool {
+	for _, w := range term {
+		if w == worker {
+			return true
+		}
+	}
+	return false
+}
+
 func peerFromDef(def *grid.ActorStart) (string, error) {
 	if def.Type != "worker" {
 		return "", fmt.Errorf("unkown worker type: %v", def.Type)
@@ -163,6 +163,13 @@ func (a *Actor) evalEvent(e *registry.WatchEvent) {
 	graphType := e.Reg.Type
 	graphName := e.Reg.Name
 
+	// A completed graph has nothing left to do,
+	// even if it is still wanted running.
+	if e.Reg.Actual == string(registry.Completed) {
+		a.stopGraph(key)
+		return
+	}
+
 	switch e.Reg.Wanted {
 	case "running":
 		conf, err := e.Reg.UnmarshalConfig()
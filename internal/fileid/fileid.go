@@ -0,0 +1,62 @@
+package fileid
+
+import (
+	"crypto/sha256"
+	"io"
+	"os"
+
+	"github.com/lytics/flo/source"
+)
+
+// PrefixLen is the most bytes of a file hashed to
+// identify it.
+const PrefixLen = 4096
+
+// Prefix identifying the file, the hash of its first
+// bytes and how many were hashed. Appending to the file
+// does not change the hash of bytes already hashed.
+func Prefix(f *os.File) ([]byte, int64, error) {
+	fi, err := f.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	n := fi.Size()
+	if n > PrefixLen {
+		n = PrefixLen
+	}
+	h, err := hash(f, n)
+	if err != nil {
+		return nil, 0, err
+	}
+	return h, n, nil
+}
+
+// Check the file still holds offset and has the prefix
+// hash of its first n bytes, returning source.ErrTruncated
+// or source.ErrReplaced if not.
+func Check(f *os.File, offset int64, prefix []byte, n int64) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if fi.Size() < offset || fi.Size() < n {
+		return source.ErrTruncated
+	}
+	h, err := hash(f, n)
+	if err != nil {
+		return err
+	}
+	if string(h) != string(prefix) {
+		return source.ErrReplaced
+	}
+	return nil
+}
+
+func hash(f *os.File, n int64) ([]byte, error) {
+	h := sha256.New()
+	_, err := io.Copy(h, io.NewSectionReader(f, 0, n))
+	if err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
@@ -7,18 +7,64 @@ import (
 	"github.com/lytics/grid"
 )
 
-// Time of event.
+// Time of event. Events from peers that only
+// set the legacy second fields are still read.
 func (m *Event) Time() time.Time {
-	return time.Unix(m.TimeUnix, 0)
+	if m.TimeMillis == 0 && m.TimeUnix != 0 {
+		return time.Unix(m.TimeUnix, 0)
+	}
+	return time.Unix(m.TimeMillis/1000, m.TimeMillis%1000*int64(time.Millisecond))
 }
 
-// Window of time the event is associated with.
+// Window of time the event is associated with. Events
+// from peers that only set the legacy second fields are
+// still read.
 func (m *Event) Window() window.Span {
-	return window.NewSpan(time.Unix(m.WindowStartUnix, 0), time.Unix(m.WindowEndUnix, 0))
+	if m.Span != nil {
+		return m.Span.Window()
+	}
+	return window.Span{m.WindowStartUnix * 1000, m.WindowEndUnix * 1000}
+}
+
+// SetTime of the event, in both millisecond and
+// legacy second resolution.
+func (m *Event) SetTime(t time.Time) {
+	m.TimeUnix = t.Unix()
+	m.TimeMillis = t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
+
+// SetWindow of the event. The legacy second fields
+// are also set, for peers which only read those.
+func (m *Event) SetWindow(s window.Span) {
+	m.WindowStartUnix = s.Start().Unix()
+	m.WindowEndUnix = s.End().Unix()
+	m.Span = NewSpan(s)
+}
+
+// MinTime of the progress, the event time the mapper
+// of the peer has progressed past.
+func (m *Progress) MinTime() time.Time {
+	return time.Unix(m.MinEventTime/1000, m.MinEventTime%1000*int64(time.Millisecond))
+}
+
+// SetMinTime of the progress, in milliseconds.
+func (m *Progress) SetMinTime(t time.Time) {
+	m.MinEventTime = t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
+
+// NewSpan message of the window span.
+func NewSpan(s window.Span) *Span {
+	return &Span{Start: s[0], End: s[1], Kind: int64(s.Kind())}
+}
+
+// Window span of the message.
+func (m *Span) Window() window.Span {
+	return window.Span{m.Start, m.End, m.Kind}
 }
 
 func init() {
 	grid.Register(Term{})
 	grid.Register(Event{})
 	grid.Register(Progress{})
+	grid.Register(ResetKeys{})
 }
@@ -10,8 +10,10 @@ It is generated from these files:
 
 It has these top-level messages:
 	Event
+	Span
 	Progress
 	Term
+	ResetKeys
 */
 package msg
 
@@ -38,6 +40,8 @@ type Event struct {
 	TimeUnix        int64  `protobuf:"varint,5,opt,name=TimeUnix" json:"TimeUnix,omitempty"`
 	WindowStartUnix int64  `protobuf:"varint,6,opt,name=WindowStartUnix" json:"WindowStartUnix,omitempty"`
 	WindowEndUnix   int64  `protobuf:"varint,7,opt,name=WindowEndUnix" json:"WindowEndUnix,omitempty"`
+	TimeMillis      int64  `protobuf:"varint,8,opt,name=TimeMillis" json:"TimeMillis,omitempty"`
+	Span            *Span  `protobuf:"bytes,9,opt,name=Span" json:"Span,omitempty"`
 }
 
 func (m *Event) Reset()                    { *m = Event{} }
@@ -94,18 +98,67 @@ func (m *Event) GetWindowEndUnix() int64 {
 	return 0
 }
 
+func (m *Event) GetTimeMillis() int64 {
+	if m != nil {
+		return m.TimeMillis
+	}
+	return 0
+}
+
+func (m *Event) GetSpan() *Span {
+	if m != nil {
+		return m.Span
+	}
+	return nil
+}
+
+type Span struct {
+	Start int64 `protobuf:"varint,1,opt,name=Start" json:"Start,omitempty"`
+	End   int64 `protobuf:"varint,2,opt,name=End" json:"End,omitempty"`
+	Kind  int64 `protobuf:"varint,3,opt,name=Kind" json:"Kind,omitempty"`
+}
+
+func (m *Span) Reset()                    { *m = Span{} }
+func (m *Span) String() string            { return proto.CompactTextString(m) }
+func (*Span) ProtoMessage()               {}
+func (*Span) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+
+func (m *Span) GetStart() int64 {
+	if m != nil {
+		return m.Start
+	}
+	return 0
+}
+
+func (m *Span) GetEnd() int64 {
+	if m != nil {
+		return m.End
+	}
+	return 0
+}
+
+func (m *Span) GetKind() int64 {
+	if m != nil {
+		return m.Kind
+	}
+	return 0
+}
+
 type Progress struct {
 	Peer         string   `protobuf:"bytes,1,opt,name=Peer" json:"Peer,omitempty"`
 	Graph        string   `protobuf:"bytes,2,opt,name=Graph" json:"Graph,omitempty"`
 	Source       []string `protobuf:"bytes,3,rep,name=Source" json:"Source,omitempty"`
 	Done         bool     `protobuf:"varint,4,opt,name=Done" json:"Done,omitempty"`
 	MinEventTime int64    `protobuf:"varint,5,opt,name=MinEventTime" json:"MinEventTime,omitempty"`
+	Term         []string `protobuf:"bytes,6,rep,name=Term" json:"Term,omitempty"`
+	GraphType    string   `protobuf:"bytes,7,opt,name=GraphType" json:"GraphType,omitempty"`
+	GraphName    string   `protobuf:"bytes,8,opt,name=GraphName" json:"GraphName,omitempty"`
 }
 
 func (m *Progress) Reset()                    { *m = Progress{} }
 func (m *Progress) String() string            { return proto.CompactTextString(m) }
 func (*Progress) ProtoMessage()               {}
-func (*Progress) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{1} }
+func (*Progress) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
 
 func (m *Progress) GetPeer() string {
 	if m != nil {
@@ -142,6 +195,27 @@ func (m *Progress) GetMinEventTime() int64 {
 	return 0
 }
 
+func (m *Progress) GetTerm() []string {
+	if m != nil {
+		return m.Term
+	}
+	return nil
+}
+
+func (m *Progress) GetGraphType() string {
+	if m != nil {
+		return m.GraphType
+	}
+	return ""
+}
+
+func (m *Progress) GetGraphName() string {
+	if m != nil {
+		return m.GraphName
+	}
+	return ""
+}
+
 type Term struct {
 	Peers []string `protobuf:"bytes,1,rep,name=Peers" json:"Peers,omitempty"`
 }
@@ -149,7 +223,7 @@ type Term struct {
 func (m *Term) Reset()                    { *m = Term{} }
 func (m *Term) String() string            { return proto.CompactTextString(m) }
 func (*Term) ProtoMessage()               {}
-func (*Term) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{2} }
+func (*Term) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{3} }
 
 func (m *Term) GetPeers() []string {
 	if m != nil {
@@ -158,31 +232,71 @@ func (m *Term) GetPeers() []string {
 	return nil
 }
 
+type ResetKeys struct {
+	GraphType string   `protobuf:"bytes,1,opt,name=GraphType" json:"GraphType,omitempty"`
+	GraphName string   `protobuf:"bytes,2,opt,name=GraphName" json:"GraphName,omitempty"`
+	Keys      []string `protobuf:"bytes,3,rep,name=Keys" json:"Keys,omitempty"`
+}
+
+func (m *ResetKeys) Reset()                    { *m = ResetKeys{} }
+func (m *ResetKeys) String() string            { return proto.CompactTextString(m) }
+func (*ResetKeys) ProtoMessage()               {}
+func (*ResetKeys) Descriptor() ([]byte, []int) { return fileDescriptor0, []int{4} }
+
+func (m *ResetKeys) GetGraphType() string {
+	if m != nil {
+		return m.GraphType
+	}
+	return ""
+}
+
+func (m *ResetKeys) GetGraphName() string {
+	if m != nil {
+		return m.GraphName
+	}
+	return ""
+}
+
+func (m *ResetKeys) GetKeys() []string {
+	if m != nil {
+		return m.Keys
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*Event)(nil), "msg.Event")
+	proto.RegisterType((*Span)(nil), "msg.Span")
 	proto.RegisterType((*Progress)(nil), "msg.Progress")
 	proto.RegisterType((*Term)(nil), "msg.Term")
+	proto.RegisterType((*ResetKeys)(nil), "msg.ResetKeys")
 }
 
 func init() { proto.RegisterFile("msg.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 258 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0x5c, 0x90, 0x4f, 0x4b, 0xc4, 0x30,
-	0x10, 0xc5, 0xc9, 0xa6, 0xad, 0xed, 0xb0, 0xa2, 0x0c, 0x22, 0x41, 0x3c, 0x94, 0xe2, 0xa1, 0x27,
-	0x2f, 0x7e, 0x05, 0x17, 0x0f, 0x22, 0x2c, 0xd9, 0x8a, 0xe7, 0xea, 0x0e, 0xb5, 0x87, 0x26, 0x25,
-	0x89, 0x7f, 0xf6, 0xee, 0xb7, 0xf3, 0x4b, 0x49, 0xa6, 0x65, 0x75, 0x3d, 0xe5, 0xbd, 0x97, 0x47,
-	0x26, 0xbf, 0x81, 0x62, 0xf0, 0xdd, 0xf5, 0xe8, 0x6c, 0xb0, 0x28, 0x07, 0xdf, 0x55, 0xdf, 0x02,
-	0xd2, 0xd5, 0x3b, 0x99, 0x80, 0x67, 0x90, 0xde, 0xb9, 0x76, 0x7c, 0x55, 0xa2, 0x14, 0x75, 0xa1,
-	0x27, 0x83, 0xa7, 0x20, 0xef, 0x69, 0xa7, 0x16, 0x9c, 0x45, 0x89, 0x08, 0xc9, 0x6d, 0x1b, 0x5a,
-	0x25, 0x4b, 0x51, 0x2f, 0x35, 0x6b, 0xbc, 0x80, 0x3c, 0x9e, 0xcd, 0x6e, 0x24, 0x95, 0x70, 0x75,
-	0xef, 0xe3, 0x5d, 0xd3, 0x0f, 0xf4, 0x68, 0xfa, 0x4f, 0x95, 0x96, 0xa2, 0x96, 0x7a, 0xef, 0xb1,
-	0x86, 0x93, 0xa7, 0xde, 0x6c, 0xed, 0xc7, 0x26, 0xb4, 0x2e, 0x70, 0x25, 0xe3, 0xca, 0xff, 0x18,
-	0xaf, 0xe0, 0x78, 0x8a, 0x56, 0x66, 0xcb, 0xbd, 0x23, 0xee, 0x1d, 0x86, 0xd5, 0x97, 0x80, 0x7c,
-	0xed, 0x6c, 0xe7, 0xc8, 0xfb, 0xf8, 0xd1, 0x35, 0x91, 0x9b, 0x79, 0x58, 0xff, 0x42, 0x2e, 0xfe,
-	0x42, 0x9e, 0x43, 0xb6, 0xb1, 0x6f, 0xee, 0x85, 0x94, 0x2c, 0x65, 0x5d, 0xe8, 0xd9, 0x31, 0xaa,
-	0x35, 0x13, 0x52, 0xae, 0x59, 0x63, 0x05, 0xcb, 0x87, 0xde, 0xf0, 0xca, 0x22, 0xc6, 0x8c, 0x74,
-	0x90, 0x55, 0x97, 0x90, 0x34, 0xe4, 0x86, 0x38, 0x2d, 0x4e, 0xf5, 0x4a, 0xf0, 0xb3, 0x93, 0x79,
-	0xce, 0x78, 0xfd, 0x37, 0x3f, 0x01, 0x00, 0x00, 0xff, 0xff, 0xcd, 0x01, 0x07, 0x7b, 0x8b, 0x01,
-	0x00, 0x00,
+	// 368 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x7d, 0x92, 0xcf, 0x4a, 0xc3, 0x40,
+	0x10, 0xc6, 0x49, 0x36, 0x69, 0x93, 0xb1, 0xa2, 0x2c, 0x22, 0x8b, 0x54, 0x91, 0xe0, 0xa1, 0xa7,
+	0x1e, 0xf4, 0x0d, 0xc4, 0xe2, 0xa1, 0x54, 0xca, 0xb6, 0xe2, 0xc1, 0x53, 0xb4, 0x4b, 0x0d, 0xb4,
+	0x9b, 0xb0, 0x1b, 0xb5, 0x7d, 0x05, 0x9f, 0xcf, 0x07, 0x72, 0x67, 0x36, 0xf4, 0xdf, 0xc1, 0x53,
+	0xbe, 0xf9, 0x66, 0x32, 0x5f, 0xf6, 0xb7, 0x81, 0x74, 0x69, 0xe7, 0xfd, 0xca, 0x94, 0x75, 0xc9,
+	0x99, 0x93, 0xd9, 0x4f, 0x08, 0xf1, 0xe0, 0x4b, 0xe9, 0x9a, 0x9f, 0x41, 0xfc, 0x68, 0xf2, 0xea,
+	0x43, 0x04, 0xd7, 0x41, 0x2f, 0x95, 0xbe, 0xe0, 0xa7, 0xc0, 0x86, 0x6a, 0x2d, 0x42, 0xf2, 0x50,
+	0x72, 0x0e, 0xd1, 0x43, 0x5e, 0xe7, 0x82, 0x39, 0xab, 0x23, 0x49, 0xf3, 0x0b, 0x48, 0xf0, 0x39,
+	0x5d, 0x57, 0x4a, 0x44, 0x34, 0xba, 0xa9, 0xb1, 0x37, 0x2d, 0x96, 0xea, 0x59, 0x17, 0x2b, 0x11,
+	0xbb, 0x1e, 0x93, 0x9b, 0x9a, 0xf7, 0xe0, 0xe4, 0xa5, 0xd0, 0xb3, 0xf2, 0x7b, 0x52, 0xe7, 0xa6,
+	0xa6, 0x91, 0x16, 0x8d, 0x1c, 0xda, 0xfc, 0x06, 0x8e, 0xbd, 0x35, 0xd0, 0x33, 0x9a, 0x6b, 0xd3,
+	0xdc, 0xbe, 0xc9, 0xaf, 0x00, 0x70, 0xf7, 0xa8, 0x58, 0x2c, 0x0a, 0x2b, 0x12, 0x1a, 0xd9, 0x71,
+	0xf8, 0x25, 0x44, 0x93, 0x2a, 0xd7, 0x22, 0x75, 0x9d, 0xa3, 0xdb, 0xb4, 0x8f, 0x30, 0xd0, 0x90,
+	0x64, 0x67, 0xf7, 0xbe, 0x8d, 0x28, 0x28, 0x99, 0x50, 0x30, 0xe9, 0x0b, 0x44, 0xe1, 0x72, 0x08,
+	0x05, 0x93, 0x28, 0x11, 0xc5, 0xd0, 0xe5, 0x13, 0x0a, 0x26, 0x49, 0x67, 0xbf, 0x01, 0x24, 0x63,
+	0x53, 0xce, 0x8d, 0xb2, 0x16, 0x07, 0xc6, 0x4a, 0x99, 0x06, 0x29, 0xe9, 0x2d, 0xe7, 0x70, 0x97,
+	0xf3, 0x39, 0xb4, 0x26, 0xe5, 0xa7, 0x79, 0x57, 0x6e, 0x19, 0x73, 0x76, 0x53, 0x11, 0xed, 0x52,
+	0x7b, 0xaa, 0x89, 0x24, 0xcd, 0x33, 0xe8, 0x8c, 0x0a, 0x4d, 0xb7, 0x86, 0x67, 0x6b, 0xa8, 0xee,
+	0x79, 0xf8, 0xde, 0x54, 0x99, 0xa5, 0xc3, 0x89, 0xdb, 0x48, 0xf3, 0x2e, 0xa4, 0x14, 0x46, 0xd7,
+	0xd4, 0xa6, 0xf4, 0xad, 0xb1, 0xe9, 0x3e, 0xe5, 0x6e, 0x65, 0xb2, 0xd3, 0x45, 0x23, 0xeb, 0xfa,
+	0x7d, 0xf8, 0xf5, 0x78, 0x0a, 0xeb, 0x8e, 0x84, 0x8b, 0x7d, 0x91, 0xbd, 0x42, 0x2a, 0x95, 0x55,
+	0xb5, 0xfb, 0x3f, 0xec, 0x7e, 0x4c, 0xf0, 0x6f, 0x4c, 0x78, 0x10, 0x43, 0x44, 0xdd, 0x8e, 0x06,
+	0x02, 0xe9, 0xb7, 0x16, 0xfd, 0xae, 0x77, 0x7f, 0x34, 0x13, 0xfa, 0x52, 0xbb, 0x02, 0x00, 0x00,
 }
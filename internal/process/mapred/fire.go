@@ -0,0 +1,304 @@
+package mapred
+
+import (
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lytics/flo/sink"
+	"github.com/lytics/flo/window"
+)
+
+// Metrics of fires per process, published by expvar.
+var (
+	pendingFires = expvar.NewMap("flo.fires.pending")
+	retriedFires = expvar.NewMap("flo.fires.retried")
+	deadFires    = expvar.NewMap("flo.fires.dead")
+)
+
+// fire of a key signaled by the trigger.
+type fire struct {
+	key      string
+	spans    []window.Span // Nil for every window of the key.
+	panes    map[window.Span]*sink.Pane
+	attempts int
+	after    time.Time
+}
+
+// merge the spans of o into this fire. A fire of every
+// window of the key absorbs any named windows, and the
+// later pane of a window replaces the earlier.
+func (f *fire) merge(o *fire) {
+	for s, pane := range o.panes {
+		if f.panes == nil {
+			f.panes = map[window.Span]*sink.Pane{}
+		}
+		f.panes[s] = pane
+	}
+	if f.spans == nil || o.spans == nil {
+		f.spans = nil
+		return
+	}
+	for _, s := range o.spans {
+		if !named(f.spans, s) {
+			f.spans = append(f.spans, s)
+		}
+	}
+}
+
+// copy of the fire, which is not changed by merges.
+func (f *fire) copy() *fire {
+	c := *f
+	if f.spans != nil {
+		c.spans = append([]window.Span{}, f.spans...)
+	}
+	if f.panes != nil {
+		c.panes = map[window.Span]*sink.Pane{}
+		for s, pane := range f.panes {
+			c.panes[s] = pane
+		}
+	}
+	return &c
+}
+
+func newFireQueue(size int) *fireQueue {
+	q := &fireQueue{
+		size:  size,
+		index: map[string]*fire{},
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// fireQueue of pending fires, in order of being signaled.
+// A key is queued at most once, later fires of a queued
+// key are merged into it. Once full, pushing blocks until
+// fires are taken.
+type fireQueue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	size     int
+	queue    []*fire
+	index    map[string]*fire
+	inflight map[*fire]bool
+	closed   bool
+}
+
+// push the fires, blocking while the queue is full. Once
+// closed pushing no longer blocks, the fires are kept for
+// the last checkpoint, see pending.
+func (q *fireQueue) push(fs []*fire) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, f := range fs {
+		if queued, ok := q.index[f.key]; ok {
+			queued.merge(f)
+			continue
+		}
+		for !q.closed && len(q.queue) >= q.size {
+			q.cond.Wait()
+		}
+		q.add(f)
+	}
+}
+
+// pop the first fire which is due, waiting for one if
+// none is. Nil is returned once the queue is closed.
+func (q *fireQueue) pop() *fire {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for {
+		if q.closed {
+			return nil
+		}
+		now := time.Now()
+		var next time.Time
+		for i, f := range q.queue {
+			if !f.after.After(now) {
+				q.queue = append(q.queue[:i], q.queue[i+1:]...)
+				delete(q.index, f.key)
+				if q.inflight == nil {
+					q.inflight = map[*fire]bool{}
+				}
+				q.inflight[f] = true
+				q.cond.Broadcast()
+				return f
+			}
+			if next.IsZero() || f.after.Before(next) {
+				next = f.after
+			}
+		}
+		var timer *time.Timer
+		if !next.IsZero() {
+			timer = time.AfterFunc(next.Sub(now), func() {
+				q.mu.Lock()
+				defer q.mu.Unlock()
+				q.cond.Broadcast()
+			})
+		}
+		q.cond.Wait()
+		if timer != nil {
+			timer.Stop()
+		}
+	}
+}
+
+// done with the popped fire f, which is queued again
+// when retry is true. Retries never block, so the queue
+// can exceed its size by the fires in flight.
+func (q *fireQueue) done(f *fire, retry bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.finish(f, retry)
+}
+
+// retry the popped fire f once after, counting the
+// attempt.
+func (q *fireQueue) retry(f *fire, after time.Time) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	f.attempts++
+	f.after = after
+	q.finish(f, true)
+}
+
+func (q *fireQueue) finish(f *fire, retry bool) {
+	delete(q.inflight, f)
+	if retry {
+		if queued, ok := q.index[f.key]; ok {
+			// The queued fire was signaled later, so
+			// its panes replace those of f.
+			attempts, after := queued.attempts, queued.after
+			f.merge(queued)
+			*queued = *f
+			if attempts > queued.attempts {
+				queued.attempts = attempts
+			}
+			queued.after = after
+		} else {
+			q.add(f)
+		}
+	}
+	q.cond.Broadcast()
+}
+
+// wait until no fires are queued or in flight, false is
+// returned when the queue is closed first.
+func (q *fireQueue) wait() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for !q.closed && (len(q.queue) > 0 || len(q.inflight) > 0) {
+		q.cond.Wait()
+	}
+	return !q.closed
+}
+
+// pending fires, queued or in flight, in order of being
+// signaled as far as known.
+func (q *fireQueue) pending() []*fire {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	fs := make([]*fire, 0, len(q.inflight)+len(q.queue))
+	for f := range q.inflight {
+		fs = append(fs, f.copy())
+	}
+	for _, f := range q.queue {
+		fs = append(fs, f.copy())
+	}
+	return fs
+}
+
+// restore fires of a checkpoint, without blocking.
+func (q *fireQueue) restore(fs []*fire) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for _, f := range fs {
+		if queued, ok := q.index[f.key]; ok {
+			queued.merge(f)
+			continue
+		}
+		q.add(f)
+	}
+}
+
+// close the queue, waking every waiter. Popping returns
+// nil once closed.
+func (q *fireQueue) close() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.closed = true
+	q.cond.Broadcast()
+}
+
+// len of the queue, not counting fires in flight.
+func (q *fireQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return len(q.queue)
+}
+
+func (q *fireQueue) add(f *fire) {
+	q.queue = append(q.queue, f)
+	q.index[f.key] = f
+	q.cond.Broadcast()
+}
+
+// fireEntry of a checkpoint, spans cannot be keys of
+// JSON objects.
+type fireEntry struct {
+	Key      string        `json:"key"`
+	Spans    []window.Span `json:"spans,omitempty"`
+	Panes    []paneEntry   `json:"panes,omitempty"`
+	Attempts int           `json:"attempts"`
+}
+
+type paneEntry struct {
+	Span window.Span `json:"span"`
+	Pane sink.Pane   `json:"pane"`
+}
+
+// marshalFires for a checkpoint.
+func marshalFires(fs []*fire) ([]byte, error) {
+	es := make([]fireEntry, 0, len(fs))
+	for _, f := range fs {
+		e := fireEntry{Key: f.key, Spans: f.spans, Attempts: f.attempts}
+		for s, pane := range f.panes {
+			e.Panes = append(e.Panes, paneEntry{Span: s, Pane: *pane})
+		}
+		es = append(es, e)
+	}
+	return json.Marshal(es)
+}
+
+// unmarshalFires of a checkpoint, which are due at once.
+func unmarshalFires(data []byte) ([]*fire, error) {
+	var es []fireEntry
+	err := json.Unmarshal(data, &es)
+	if err != nil {
+		return nil, fmt.Errorf("mapred: failed to restore fires: %v", err)
+	}
+	fs := make([]*fire, 0, len(es))
+	for _, e := range es {
+		f := &fire{key: e.Key, spans: e.Spans, attempts: e.Attempts}
+		for _, pe := range e.Panes {
+			if f.panes == nil {
+				f.panes = map[window.Span]*sink.Pane{}
+			}
+			pane := pe.Pane
+			f.panes[pe.Span] = &pane
+		}
+		fs = append(fs, f)
+	}
+	return fs, nil
+}
@@ -0,0 +1,146 @@
+package mapred
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/sink"
+	"github.com/lytics/flo/window"
+)
+
+var (
+	t0 = time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s0 = window.NewSpan(t0, t0.Add(1*time.Minute))
+	s1 = window.NewSpan(t0.Add(1*time.Minute), t0.Add(2*time.Minute))
+)
+
+func paned(key string, s window.Span, timing sink.Timing) *fire {
+	return &fire{
+		key:   key,
+		spans: []window.Span{s},
+		panes: map[window.Span]*sink.Pane{s: {Timing: timing}},
+	}
+}
+
+func TestFireQueuePushBlocksWhenFull(t *testing.T) {
+	q := newFireQueue(1)
+	q.push([]*fire{{key: "a"}})
+
+	pushed := make(chan struct{})
+	go func() {
+		q.push([]*fire{{key: "b"}})
+		close(pushed)
+	}()
+	select {
+	case <-pushed:
+		t.Fatal("expected push to block while full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	// A queued key is merged without blocking.
+	q.push([]*fire{{key: "a"}})
+
+	if f := q.pop(); f.key != "a" {
+		t.Fatalf("expected key a, found: %v", f.key)
+	}
+	select {
+	case <-pushed:
+	case <-time.After(1 * time.Second):
+		t.Fatal("expected push once popped")
+	}
+	if q.len() != 1 {
+		t.Fatalf("expected one queued fire, found: %v", q.len())
+	}
+}
+
+func TestFireQueueMergeDuringBackoff(t *testing.T) {
+	q := newFireQueue(10)
+	q.push([]*fire{paned("a", s0, sink.Early)})
+
+	f := q.pop()
+	after := time.Now().Add(1 * time.Hour)
+	q.retry(f, after)
+
+	// Fired again while backing off, the fire is merged
+	// and keeps its backoff.
+	q.push([]*fire{paned("a", s1, sink.OnTime)})
+	fs := q.pending()
+	if len(fs) != 1 {
+		t.Fatalf("expected one pending fire, found: %v", len(fs))
+	}
+	f = fs[0]
+	if f.attempts != 1 || !f.after.Equal(after) || len(f.spans) != 2 || len(f.panes) != 2 {
+		t.Fatalf("expected merged fire in backoff, found: %+v", f)
+	}
+}
+
+func TestFireQueueRetryMerge(t *testing.T) {
+	q := newFireQueue(10)
+	q.push([]*fire{paned("a", s0, sink.Early)})
+	f := q.pop()
+
+	// Fired again while in flight, then retried, the
+	// later pane is kept.
+	q.push([]*fire{paned("a", s0, sink.OnTime)})
+	q.retry(f, time.Time{})
+
+	fs := q.pending()
+	if len(fs) != 1 {
+		t.Fatalf("expected one pending fire, found: %v", len(fs))
+	}
+	f = fs[0]
+	if f.attempts != 1 || len(f.spans) != 1 || f.panes[s0].Timing != sink.OnTime {
+		t.Fatalf("expected merged fire with on-time pane, found: %+v", f)
+	}
+
+	// A fire of every window absorbs named windows.
+	f = q.pop()
+	q.push([]*fire{{key: "a"}})
+	q.done(f, true)
+	if f = q.pop(); f.spans != nil {
+		t.Fatalf("expected fire of every window, found: %v", f.spans)
+	}
+}
+
+func TestFireQueueCheckpoint(t *testing.T) {
+	q := newFireQueue(10)
+	q.push([]*fire{paned("a", s0, sink.Late), {key: "b"}})
+	q.pop()
+	q.close()
+
+	if q.pop() != nil {
+		t.Fatal("expected no fire once closed")
+	}
+	if q.wait() {
+		t.Fatal("expected wait to fail once closed")
+	}
+
+	// Fires after close, and fires in flight, are
+	// pending for the last checkpoint.
+	q.push([]*fire{{key: "c"}})
+	data, err := marshalFires(q.pending())
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, err := unmarshalFires(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	restored := newFireQueue(10)
+	restored.restore(fs)
+	found := map[string]*fire{}
+	for restored.len() > 0 {
+		f := restored.pop()
+		found[f.key] = f
+	}
+	if len(found) != 3 {
+		t.Fatalf("expected three restored fires, found: %v", found)
+	}
+	if a := found["a"]; len(a.spans) != 1 || a.panes[s0].Timing != sink.Late {
+		t.Fatalf("expected restored pane, found: %+v", a)
+	}
+	if found["b"].spans != nil {
+		t.Fatalf("expected fire of every window, found: %v", found["b"].spans)
+	}
+}
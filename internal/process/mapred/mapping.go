@@ -1,6 +1,9 @@
 package mapred
 
 import (
+	"context"
+	"encoding/json"
+	"fmt"
 	"io"
 	"time"
 
@@ -12,7 +15,15 @@ import (
 )
 
 func (p *Process) consume(src source.Source) error {
-	err := src.Init(p.ctx, nil)
+	name := src.Metadata().Name
+	cp, err := p.sourceCheckpoint(name)
+	if err != nil {
+		return err
+	}
+	if cp != nil {
+		p.logger.Printf("mapper resuming source: %v", name)
+	}
+	err = src.Init(p.ctx, cp)
 	if err != nil {
 		return err
 	}
@@ -38,9 +49,68 @@ func (p *Process) consume(src source.Source) error {
 		if err != nil {
 			return err
 		}
+		if cp := item.Checkpoint(); cp != nil {
+			p.mu.Lock()
+			p.positions[name] = cp
+			p.mu.Unlock()
+		}
 	}
 }
 
+// encodedCheckpoint of a source, its type is needed to
+// decode it.
+type encodedCheckpoint struct {
+	Type string `json:"type"`
+	Data []byte `json:"data"`
+}
+
+// sourceCheckpoint of the named source, nil when none
+// exists.
+func (p *Process) sourceCheckpoint(name string) (interface{}, error) {
+	data, err := p.db.GetCheckpoint(p.ctx, "source."+name)
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, nil
+	}
+	var e encodedCheckpoint
+	err = json.Unmarshal(data, &e)
+	if err != nil {
+		return nil, fmt.Errorf("mapred: invalid checkpoint of source: %v, error: %v", name, err)
+	}
+	return codec.Unmarshal(e.Data, e.Type)
+}
+
+// checkpointSources puts the position of each source,
+// which is the checkpoint of the last item processed.
+// Resuming from it processes that item again, so items
+// are processed at least once.
+func (p *Process) checkpointSources(ctx context.Context) error {
+	p.mu.Lock()
+	positions := make(map[string]interface{}, len(p.positions))
+	for name, cp := range p.positions {
+		positions[name] = cp
+	}
+	p.mu.Unlock()
+
+	for name, cp := range positions {
+		dataType, data, err := codec.Marshal(cp)
+		if err != nil {
+			return fmt.Errorf("mapred: failed to checkpoint source: %v, error: %v", name, err)
+		}
+		data, err = json.Marshal(encodedCheckpoint{Type: dataType, Data: data})
+		if err != nil {
+			return err
+		}
+		err = p.db.PutCheckpoint(ctx, "source."+name, data)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (p *Process) process(item *source.Item) error {
 	if item == nil || item.Value() == nil {
 		return nil
@@ -81,13 +151,17 @@ func (p *Process) shuffle(e graph.Event) error {
 	}
 	receiver := p.ring.Reducer(e.Key, p.graphType, p.graphName)
 	p.logger.Printf("sending to: %v, event: (%v), window: %v", receiver, e.Data, e.Window)
-	_, err = p.send(10*time.Second, receiver, &msg.Event{
-		Key:             e.Key,
-		Data:            data,
-		DataType:        dataType,
-		TimeUnix:        e.Time.UTC().Unix(),
-		WindowEndUnix:   e.Window.End().UTC().Unix(),
-		WindowStartUnix: e.Window.Start().UTC().Unix(),
-	})
-	return err
+	m := &msg.Event{
+		Key:      e.Key,
+		Data:     data,
+		DataType: dataType,
+	}
+	m.SetTime(e.Time)
+	m.SetWindow(e.Window)
+	_, err = p.send(10*time.Second, receiver, m)
+	if err != nil {
+		return err
+	}
+	p.mapped(e.Time)
+	return nil
 }
@@ -2,33 +2,63 @@ package mapred
 
 import (
 	"context"
+	"expvar"
 	"fmt"
 	"log"
 	"os"
+	"sync"
 	"time"
 
 	"github.com/lytics/flo/graph"
 	"github.com/lytics/flo/internal/codec"
 	"github.com/lytics/flo/internal/msg"
 	"github.com/lytics/flo/internal/schedule"
+	"github.com/lytics/flo/progress"
 	"github.com/lytics/flo/sink"
 	"github.com/lytics/flo/source"
 	"github.com/lytics/flo/storage"
+	"github.com/lytics/flo/trigger"
+	"github.com/lytics/flo/window"
 	"github.com/lytics/grid"
 	"golang.org/x/sync/errgroup"
 )
 
+// checkpointEvery is how often the state of the
+// trigger, when it has state, and the windows
+// delayed by a cache are made durable.
+const checkpointEvery = 10 * time.Second
+
+// progressEvery is how often the mapper sends its
+// progress to every reducer, when it has progressed.
+const progressEvery = 1 * time.Second
+
+// reportEvery is how often a mapper which is done
+// repeats its report, until the end of stream.
+const reportEvery = 30 * time.Second
+
+// compactEvery is how often expired windows are
+// garbage collected, when the graph defines
+// a retention.
+const compactEvery = 1 * time.Minute
+
 type Open func(name string) (*storage.DB, error)
 
 type Send func(timeout time.Duration, receiver string, msg interface{}) (interface{}, error)
 
 type Listen func(name string) (<-chan grid.Request, func() error, error)
 
+// Name of the process, and its mailbox, for the graph
+// running under the parent worker.
+func Name(parent, graphType, graphName string) string {
+	return fmt.Sprintf("%v-%v-%v", parent, graphType, graphName)
+}
+
 // New map and reduce process.
 func New(parent, graphType, graphName string, conf []byte, def *graph.Definition, o Open, s Send, l Listen) *Process {
-	id := fmt.Sprintf("%v-%v-%v", parent, graphType, graphName)
+	id := Name(parent, graphType, graphName)
 	return &Process{
 		id:        id,
+		parent:    parent,
 		graphType: graphType,
 		graphName: graphName,
 		def:       def,
@@ -37,13 +67,17 @@ func New(parent, graphType, graphName string, conf []byte, def *graph.Definition
 		send:      s,
 		listen:    l,
 		schedule:  make(chan *schedule.Ring),
+		ended:     make(chan struct{}),
+		positions: map[string]interface{}{},
 		logger:    log.New(os.Stderr, id+": ", log.LstdFlags),
 	}
 }
 
 // Process for mapping and reducing.
 type Process struct {
+	mu        sync.Mutex
 	id        string
+	parent    string
 	graphType string
 	graphName string
 	ctx       context.Context
@@ -59,8 +93,17 @@ type Process struct {
 	listen    Listen
 	sources   []source.Source
 	sinks     []sink.Sink
+	dead      []sink.Sink
+	fires     *fireQueue
+	trigger   trigger.Trigger
 	messages  <-chan grid.Request
 	receivers []string
+	ended     chan struct{}          // Closed on end of stream.
+	positions map[string]interface{} // Checkpoint of each source.
+	latest    time.Time              // Latest event time mapped.
+	finish    bool                   // True once every source is done.
+	progress  map[string]time.Time   // Progress of each mapper.
+	watermark time.Time
 }
 
 // String description of process.
@@ -84,6 +127,7 @@ func (p *Process) Run() error {
 	if err != nil {
 		return err
 	}
+	defer p.db.Close()
 
 	p.logger.Printf("waiting for ring")
 	r, open := <-p.schedule
@@ -93,6 +137,11 @@ func (p *Process) Run() error {
 	p.ring = r
 	p.logger.Printf("received ring: %v", p.ring)
 
+	p.def, err = p.def.Setup(p.graphType, p.graphName, p.conf)
+	if err != nil {
+		return err
+	}
+
 	p.sources, err = p.def.From().Setup(p.graphType, p.graphName, p.conf)
 	if err != nil {
 		return err
@@ -103,6 +152,35 @@ func (p *Process) Run() error {
 		return err
 	}
 
+	policy := p.def.Fire()
+	if policy.OnFailure == graph.DeadLetter {
+		if policy.DeadLetter == nil {
+			return fmt.Errorf("mapred: dead letter failure without dead letter sinks")
+		}
+		p.dead, err = policy.DeadLetter.Setup(p.graphType, p.graphName, p.conf)
+		if err != nil {
+			return err
+		}
+	}
+	p.fires = newFireQueue(policy.Pending)
+
+	p.trigger, err = p.def.Trigger().Setup(p.graphType, p.graphName, p.conf)
+	if err != nil {
+		return err
+	}
+
+	// Triggers in processing time share the timers of
+	// the process, rather than each running its own.
+	timers := trigger.NewTimers()
+	if tm, ok := p.trigger.(trigger.Timer); ok {
+		tm.Timing(timers)
+	}
+
+	err = p.restore()
+	if err != nil {
+		return err
+	}
+
 	messages, close, err := p.listen(p.id)
 	if err != nil {
 		return err
@@ -110,11 +188,34 @@ func (p *Process) Run() error {
 	defer close()
 	p.messages = messages
 
+	// Closing the fire queue unblocks the trigger
+	// and the firer once the run is done.
+	go func() {
+		<-ctx.Done()
+		p.fires.close()
+	}()
+
 	eg.Go(p.runMap)
+	eg.Go(p.runProgress)
 	eg.Go(p.runRed)
 	eg.Go(p.runTrig)
+	eg.Go(p.runFire)
+	eg.Go(p.runCompact)
+	eg.Go(func() error {
+		timers.Run(ctx.Done())
+		return nil
+	})
+
+	err = eg.Wait()
 
-	return eg.Wait()
+	// A last checkpoint once the trigger and firer are
+	// done, so fires still pending are kept, the run's
+	// context is already done.
+	cerr := p.checkpoint(context.Background())
+	if cerr != nil {
+		p.logger.Printf("failed checkpointing: %v", cerr)
+	}
+	return err
 }
 
 func (p *Process) SetRing(r *schedule.Ring) {
@@ -129,6 +230,7 @@ func (p *Process) runMap() error {
 	defer p.logger.Print("mapper exited")
 
 	p.logger.Printf("mapper consuming %v sources", len(p.sources))
+	var done []progress.SourceDone
 	for _, src := range p.sources {
 		select {
 		case <-p.ctx.Done():
@@ -139,19 +241,86 @@ func (p *Process) runMap() error {
 		if err != nil {
 			return err
 		}
+		done = append(done, progress.SourceDone{
+			Graph:  p.graphType + "." + p.graphName,
+			Source: src.Metadata().Name,
+		})
 	}
 
-	return nil
+	// Consume also returns when the context is done,
+	// which is not the end of the stream.
+	select {
+	case <-p.ctx.Done():
+		return nil
+	default:
+	}
+	// The mapper has no more events, so its progress
+	// is its latest event time, the end of stream is
+	// left to the leader.
+	p.finished()
+	return p.report(done)
+}
+
+// report to the leader that all sources of the mapper
+// are done. Every shuffle has been acked by this point,
+// since sending an event waits for its reducer. The
+// report is repeated until the end of stream arrives, so
+// a leader which restarted still learns of it. It names
+// the term shuffled on, so a leader of another term can
+// ignore it.
+func (p *Process) report(done []progress.SourceDone) error {
+	m := &msg.Progress{
+		Peer:      p.parent,
+		Graph:     p.graphType + "." + p.graphName,
+		GraphType: p.graphType,
+		GraphName: p.graphName,
+		Done:      true,
+		Term:      p.ring.Workers(),
+	}
+	for _, d := range done {
+		m.Source = append(m.Source, d.Source)
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return nil
+		case <-p.ended:
+			return nil
+		case <-timer.C:
+			_, err := p.send(10*time.Second, "leader", m)
+			if err == nil {
+				p.logger.Printf("mapper reported end of stream for sources: %v", m.Source)
+				timer.Reset(reportEvery)
+				continue
+			}
+			p.logger.Printf("failed reporting end of stream: %v", err)
+			timer.Reset(10 * time.Second)
+		}
+	}
 }
 
 func (p *Process) runRed() error {
 	p.logger.Print("reducer running")
 	defer p.logger.Printf("reducer exited")
 
+	// Checkpoints are taken between reduces, so the
+	// trigger's state matches the windows written.
+	ticker := time.NewTicker(checkpointEvery)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case <-p.ctx.Done():
 			return nil
+		case <-ticker.C:
+			err := p.checkpoint(p.ctx)
+			if err != nil {
+				p.logger.Printf("failed checkpointing: %v", err)
+			}
 		case req := <-p.messages:
 			switch m := req.Msg().(type) {
 			case *msg.Event:
@@ -171,6 +340,47 @@ func (p *Process) runRed() error {
 				} else {
 					req.Ack()
 				}
+			case *msg.ResetKeys:
+				// Keys not reduced by this process have
+				// no windows, so resetting them is a no-op.
+				err := p.reset(m.Keys)
+				if err != nil {
+					req.Respond(err)
+				} else {
+					req.Ack()
+				}
+			case *msg.Progress:
+				if !m.Done {
+					if p.progressed(m.Peer, m.MinTime()) {
+						expiry, _ := p.expiry()
+						p.trigger.Heuristic(&progress.Heuristic{
+							Watermark: p.watermarkOf(),
+							Expiry:    expiry,
+						})
+					}
+					req.Ack()
+					continue
+				}
+				// The leader sends end of stream once every
+				// mapper is done. The ack is only sent after
+				// the trigger has handled it, and the fires it
+				// queued are done, so the leader knows the
+				// final drain happened. Waiting on the fires
+				// is left to its own goroutine, so reducing
+				// goes on while fires are retried.
+				select {
+				case <-p.ended:
+				default:
+					close(p.ended)
+				}
+				p.trigger.Heuristic(&progress.Heuristic{EOS: true})
+				go func(req grid.Request) {
+					if p.fires.wait() {
+						req.Ack()
+					} else {
+						req.Respond(fmt.Errorf("mapred: stopped before fires were done"))
+					}
+				}(req)
 			}
 		}
 	}
@@ -180,22 +390,602 @@ func (p *Process) runTrig() error {
 	p.logger.Print("trigger running")
 	defer p.logger.Printf("trigger exited")
 
-	signal := func(keys []string) error {
-		for _, sink := range p.sinks {
-			err := p.db.Drain(p.ctx, keys, sink.Give)
+	t := p.trigger
+
+	// The trigger is set up by the run, so it is
+	// stopped when the run's context is done.
+	go func() {
+		<-p.ctx.Done()
+		t.Stop()
+	}()
+
+	if r, ok := t.(trigger.Resetter); ok {
+		r.Resetting(p.reset)
+	}
+
+	// Signals only queue fires, emitting them is left
+	// to the firer, so a failing sink blocks the trigger
+	// only once the queue is full.
+	if st, ok := t.(trigger.Spanner); ok {
+		return st.StartSpans(func(kss []trigger.KeySpan) error {
+			p.fires.push(firesOf(kss))
+			return nil
+		})
+	}
+
+	return t.Start(func(keys []string) error {
+		fs := make([]*fire, 0, len(keys))
+		for _, key := range keys {
+			fs = append(fs, &fire{key: key})
+		}
+		p.fires.push(fs)
+		return nil
+	})
+}
+
+func (p *Process) runFire() error {
+	p.logger.Print("firer running")
+	defer p.logger.Printf("firer exited")
+
+	pendingFires.Set(p.id, expvar.Func(func() interface{} {
+		return p.fires.len()
+	}))
+	defer pendingFires.Delete(p.id)
+
+	policy := p.def.Fire()
+	for {
+		f := p.fires.pop()
+		if f == nil {
+			return nil
+		}
+		err := p.fire(p.sinks, f)
+		if err == nil {
+			p.fires.done(f, false)
+			continue
+		}
+		select {
+		case <-p.ctx.Done():
+			// Kept for the last checkpoint.
+			p.fires.done(f, true)
+			return nil
+		default:
+		}
+
+		attempts := f.attempts + 1
+		if policy.OnFailure == graph.RetryForever || attempts <= policy.Retries {
+			delay := policy.Delay(attempts)
+			p.logger.Printf("failed firing key: %v, attempt: %v, retrying in: %v, error: %v", f.key, attempts, delay, err)
+			retriedFires.Add(p.id, 1)
+			p.fires.retry(f, time.Now().Add(delay))
+			continue
+		}
+		p.fires.done(f, false)
+
+		if policy.OnFailure != graph.DeadLetter {
+			return fmt.Errorf("mapred: failed firing key: %v, after %v attempts: %v", f.key, attempts, err)
+		}
+		p.logger.Printf("failed firing key: %v, after %v attempts, giving to dead letter sinks, error: %v", f.key, attempts, err)
+		err = p.fire(p.dead, f)
+		if err != nil {
+			return fmt.Errorf("mapred: failed giving key: %v, to dead letter sinks: %v", f.key, err)
+		}
+		deadFires.Add(p.id, 1)
+	}
+}
+
+// fire the windows of the key to the sinks, discarding
+// them after when the trigger discards.
+func (p *Process) fire(sinks []sink.Sink, f *fire) error {
+	discarding := false
+	if d, ok := p.trigger.(trigger.Discarder); ok {
+		discarding = d.Discarding()
+	}
+	switch {
+	case discarding && f.spans == nil:
+		return p.discard(sinks, f)
+	case discarding:
+		return p.discardSpans(sinks, f)
+	case f.spans == nil:
+		return p.emit(sinks, f)
+	default:
+		return p.emitSpans(sinks, f)
+	}
+}
+
+// emit the windows of the key to the sinks.
+func (p *Process) emit(sinks []sink.Sink, f *fire) error {
+	stored := map[window.Span][]interface{}{}
+	err := p.db.Drain(p.ctx, []string{f.key}, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		stored[s] = vs
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = p.give(sinks, f, stored)
+	if err != nil {
+		return err
+	}
+	p.compactClosed(f, stored)
+	return nil
+}
+
+// emitSpans emits only the named windows of the key. The
+// stored windows overlapping them are drained, since the
+// named windows may be assembled from several.
+func (p *Process) emitSpans(sinks []sink.Sink, f *fire) error {
+	stored := map[window.Span][]interface{}{}
+	filter := func(key string, s window.Span) bool {
+		return holds(f.spans, s)
+	}
+	err := p.db.Drain(p.ctx, []string{f.key}, filter, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		stored[s] = vs
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = p.give(sinks, f, stored)
+	if err != nil {
+		return err
+	}
+	p.compactClosed(f, stored)
+	return nil
+}
+
+// compactClosed deletes the closed ordinal windows given
+// by the fire. They take no more events, so once emitted
+// nothing is lost, and they would otherwise never expire.
+// Windows which fail to be deleted are emitted again by
+// a later fire.
+func (p *Process) compactClosed(f *fire, given map[window.Span][]interface{}) {
+	var closed []window.Span
+	for s := range given {
+		if s.Kind() == window.Closed && (f.spans == nil || named(f.spans, s)) {
+			closed = append(closed, s)
+		}
+	}
+	if len(closed) == 0 {
+		return
+	}
+	err := p.db.Apply(p.ctx, f.key, func(state window.State) error {
+		deleted := map[window.Span][]interface{}{}
+		for _, s := range closed {
+			deleted[s] = state.Get(s)
+			state.Del(s)
+		}
+		p.forget(f.key, deleted)
+		return nil
+	})
+	if err != nil {
+		p.logger.Printf("failed compacting closed windows of key: %v, error: %v", f.key, err)
+	}
+}
+
+// discard the windows of the key after giving them to
+// the sinks. The key is read and reset atomically, so no
+// event merged in between is lost, but the windows are
+// given after, so no sink is called while the key is
+// held. Windows are put back when giving fails.
+func (p *Process) discard(sinks []sink.Sink, f *fire) error {
+	var stored, taken map[window.Span][]interface{}
+	err := p.db.Apply(p.ctx, f.key, func(state window.State) error {
+		stored = map[window.Span][]interface{}{}
+		for s, vs := range state.Windows() {
+			stored[s] = vs
+		}
+		p.def.Reset(state)
+		// Windows kept by the reset are not taken.
+		kept := state.Windows()
+		taken = map[window.Span][]interface{}{}
+		for s, vs := range stored {
+			if _, ok := kept[s]; !ok {
+				taken[s] = vs
+			}
+		}
+		p.forget(f.key, taken)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = p.give(sinks, f, stored)
+	if err != nil {
+		return p.untake(f.key, taken, err)
+	}
+	return nil
+}
+
+// discardSpans gives the named windows of the key to the
+// sinks, after deleting the stored windows which are
+// named. Stored windows which only hold part of a named
+// window, such as panes, are left for compaction.
+func (p *Process) discardSpans(sinks []sink.Sink, f *fire) error {
+	var stored, taken map[window.Span][]interface{}
+	err := p.db.Apply(p.ctx, f.key, func(state window.State) error {
+		stored = map[window.Span][]interface{}{}
+		for _, s := range f.spans {
+			for s0, vs := range state.Overlapping(s) {
+				stored[s0] = vs
+			}
+		}
+		taken = map[window.Span][]interface{}{}
+		for _, s := range f.spans {
+			if vs, ok := stored[s]; ok {
+				taken[s] = vs
+				state.Del(s)
+			}
+		}
+		p.forget(f.key, taken)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	err = p.give(sinks, f, stored)
+	if err != nil {
+		return p.untake(f.key, taken, err)
+	}
+	return nil
+}
+
+// untake puts back the windows taken by a discarding
+// fire which failed with cause, so a retry gives them
+// again. It is done even when the run is stopping.
+func (p *Process) untake(key string, taken map[window.Span][]interface{}, cause error) error {
+	if len(taken) == 0 {
+		return cause
+	}
+	err := p.db.Apply(context.Background(), key, func(state window.State) error {
+		return p.def.Restore(taken, state)
+	})
+	if err != nil {
+		return fmt.Errorf("mapred: failed putting back windows of key: %v, error: %v, after: %v", key, err, cause)
+	}
+	return cause
+}
+
+// give the windows of the key, as assembled from the
+// stored windows, to every sink. When the fire names
+// spans only those windows are given, with their panes.
+func (p *Process) give(sinks []sink.Sink, f *fire, stored map[window.Span][]interface{}) error {
+	ws, err := p.def.Assemble(stored)
+	if err != nil {
+		return err
+	}
+	for s, vs := range ws {
+		if f.spans != nil && !named(f.spans, s) {
+			continue
+		}
+		ctx := p.ctx
+		if pane, ok := f.panes[s]; ok {
+			ctx = sink.WithPane(ctx, *pane)
+		}
+		for _, sink := range sinks {
+			err := sink.Give(ctx, s, f.key, vs)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// reset the state of the keys, forgetting their deleted
+// windows in the trigger.
+func (p *Process) reset(keys []string) error {
+	for _, key := range keys {
+		err := p.db.Apply(p.ctx, key, func(state window.State) error {
+			deleted := map[window.Span][]interface{}{}
+			for s, vs := range state.Windows() {
+				deleted[s] = vs
+			}
+			p.def.Reset(state)
+			for s := range state.Windows() {
+				delete(deleted, s)
+			}
+			p.forget(key, deleted)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// forget the deleted windows of the key in the trigger,
+// if it keeps state per window. It is called while the
+// key is held, so no event is counted in between.
+func (p *Process) forget(key string, deleted map[window.Span][]interface{}) {
+	f, ok := p.trigger.(trigger.Forgetter)
+	if !ok || len(deleted) == 0 {
+		return
+	}
+	spans := make([]window.Span, 0, len(deleted))
+	for s := range deleted {
+		spans = append(spans, s)
+	}
+	f.Forget(key, spans)
+}
+
+// restore the state of the trigger, and the fires it
+// signaled which were still pending, from the last
+// checkpoint, if any.
+func (p *Process) restore() error {
+	if c, ok := p.trigger.(trigger.Checkpointer); ok {
+		data, err := p.db.GetCheckpoint(p.ctx, "trigger")
+		if err != nil {
+			return err
+		}
+		if data != nil {
+			p.logger.Print("restoring trigger from checkpoint")
+			err = c.Restore(data)
 			if err != nil {
 				return err
 			}
 		}
+	}
+	data, err := p.db.GetCheckpoint(p.ctx, "fires")
+	if err != nil {
+		return err
+	}
+	if data == nil {
 		return nil
 	}
+	fs, err := unmarshalFires(data)
+	if err != nil {
+		return err
+	}
+	p.logger.Printf("restoring %v pending fires from checkpoint", len(fs))
+	p.fires.restore(fs)
+	return nil
+}
+
+// checkpoint the state of the trigger, if it has state,
+// the pending fires, and the positions of sources, then
+// flush the windows delayed by a cache, so all are durable
+// once checkpoint returns.
+// The trigger goes first, a fire it signals in between
+// is then fired again after a restore, rather than lost.
+func (p *Process) checkpoint(ctx context.Context) error {
+	if c, ok := p.trigger.(trigger.Checkpointer); ok {
+		data, err := c.Checkpoint()
+		if err != nil {
+			return err
+		}
+		err = p.db.PutCheckpoint(ctx, "trigger", data)
+		if err != nil {
+			return err
+		}
+	}
+	data, err := marshalFires(p.fires.pending())
+	if err != nil {
+		return err
+	}
+	err = p.db.PutCheckpoint(ctx, "fires", data)
+	if err != nil {
+		return err
+	}
+	err = p.checkpointSources(ctx)
+	if err != nil {
+		return err
+	}
+	return p.db.Flush(ctx)
+}
 
-	return p.def.Trigger().Start(signal)
+func (p *Process) runCompact() error {
+	keep := p.def.Retention()
+	if keep <= 0 {
+		return nil
+	}
+
+	p.logger.Print("compactor running")
+	defer p.logger.Printf("compactor exited")
+
+	ticker := time.NewTicker(compactEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return nil
+		case <-ticker.C:
+			expiry, ok := p.expiry()
+			if !ok {
+				continue
+			}
+			err := p.db.Compact(p.ctx, expiry)
+			if err != nil {
+				p.logger.Printf("failed compacting windows before: %v, error: %v", expiry, err)
+			}
+		}
+	}
+}
+
+// runProgress sends the progress of the mapper to every
+// reducer, see sendProgress.
+func (p *Process) runProgress() error {
+	p.logger.Print("progress reporter running")
+	defer p.logger.Printf("progress reporter exited")
+
+	ticker := time.NewTicker(progressEvery)
+	defer ticker.Stop()
+
+	var sent time.Time
+	for {
+		select {
+		case <-p.ctx.Done():
+			return nil
+		case <-ticker.C:
+			sent = p.sendProgress(sent)
+		}
+	}
+}
+
+// sendProgress of the mapper to every reducer, when it
+// has progressed since sent, returning the progress sent.
+// The progress is the latest event time mapped minus the
+// out-of-orderness allowed, or the latest event time once
+// the mapper is finished. Events are sent and acked one
+// at a time, so every event before the progress was
+// received by its reducer.
+func (p *Process) sendProgress(sent time.Time) time.Time {
+	p.mu.Lock()
+	latest := p.latest
+	finish := p.finish
+	p.mu.Unlock()
+	if latest.IsZero() {
+		return sent
+	}
+	min := latest
+	if !finish {
+		min = latest.Add(-p.def.OutOfOrder())
+	}
+	if !min.After(sent) {
+		return sent
+	}
+	m := &msg.Progress{
+		Peer:  p.parent,
+		Graph: p.graphType + "." + p.graphName,
+	}
+	m.SetMinTime(min)
+	for _, receiver := range p.ring.Reducers(p.graphType, p.graphName) {
+		_, err := p.send(10*time.Second, receiver, m)
+		if err != nil {
+			p.logger.Printf("failed sending progress to: %v, error: %v", receiver, err)
+			return sent
+		}
+	}
+	return min
+}
+
+// mapped event time ts by the mapper.
+func (p *Process) mapped(ts time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if ts.After(p.latest) {
+		p.latest = ts
+	}
+}
+
+// finished mapping, no events come after the latest.
+func (p *Process) finished() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.finish = true
+}
+
+// progressed mapper of the peer, which has sent every
+// event before ts. The watermark is the progress of the
+// mapper furthest behind, it is unknown until every
+// mapper of the ring has progressed. True is returned
+// when the watermark advanced.
+func (p *Process) progressed(peer string, ts time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.progress == nil {
+		p.progress = map[string]time.Time{}
+	}
+	if ts.After(p.progress[peer]) {
+		p.progress[peer] = ts
+	}
+	if p.ring == nil {
+		return false
+	}
+	var min time.Time
+	for _, worker := range p.ring.Workers() {
+		ts, ok := p.progress[worker]
+		if !ok {
+			return false
+		}
+		if min.IsZero() || ts.Before(min) {
+			min = ts
+		}
+	}
+	if min.After(p.watermark) {
+		p.watermark = min
+		return true
+	}
+	return false
+}
+
+// watermarkOf the process.
+func (p *Process) watermarkOf() time.Time {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.watermark
+}
+
+// expiry time of windows, windows ending before it are
+// expired. False is returned when the graph keeps its
+// windows forever, or no watermark exists yet.
+func (p *Process) expiry() (time.Time, bool) {
+	keep := p.def.Retention()
+	if keep <= 0 {
+		return time.Time{}, false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.watermark.IsZero() {
+		return time.Time{}, false
+	}
+	return p.watermark.Add(-keep), true
+}
+
+// firesOf the named windows, one fire per key. A key
+// signaled with every window is fired with every window.
+func firesOf(kss []trigger.KeySpan) []*fire {
+	keys := map[string]*fire{}
+	var fs []*fire
+	for _, ks := range kss {
+		o := &fire{key: ks.Key}
+		if !ks.Every {
+			o.spans = []window.Span{ks.Span}
+			if ks.Pane != nil {
+				o.panes = map[window.Span]*sink.Pane{ks.Span: ks.Pane}
+			}
+		}
+		f, ok := keys[ks.Key]
+		if !ok {
+			keys[ks.Key] = o
+			fs = append(fs, o)
+			continue
+		}
+		f.merge(o)
+	}
+	return fs
+}
+
+// named is true when s is one of the spans.
+func named(spans []window.Span, s window.Span) bool {
+	for _, r := range spans {
+		if r == s {
+			return true
+		}
+	}
+	return false
+}
+
+// holds is true when stored span s may hold values of
+// one of the spans.
+func holds(spans []window.Span, s window.Span) bool {
+	for _, r := range spans {
+		if r == s || r.Overlap(s) {
+			return true
+		}
+	}
+	return false
 }
 
 // Stop mapping, reducing and triggering.
 func (p *Process) Stop() {
-	p.def.Trigger().Stop()
 	p.cancel()
 	p.logger.Printf("stopping")
 }
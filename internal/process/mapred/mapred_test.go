@@ -0,0 +1,567 @@
+package mapred
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/graph"
+	"github.com/lytics/flo/internal/msg"
+	"github.com/lytics/flo/internal/schedule"
+	"github.com/lytics/flo/sink"
+	"github.com/lytics/flo/sink/funcsink"
+	"github.com/lytics/flo/source/linefile"
+	"github.com/lytics/flo/storage"
+	"github.com/lytics/flo/storage/driver/memdriver"
+	"github.com/lytics/flo/trigger"
+	"github.com/lytics/flo/window"
+	"github.com/lytics/grid"
+)
+
+// newTestProcess with memory storage, the graph g and
+// the trigger t, which is not started.
+func newTestProcess(t *testing.T, g *graph.Graph, trig trigger.Trigger) *Process {
+	db, err := storage.Open("test", memdriver.Cfg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	g.Trigger(trigger.Fresh(func() trigger.Trigger { return trig }))
+	return &Process{
+		id:        "test",
+		ctx:       context.Background(),
+		db:        db,
+		def:       g.Definition(),
+		fires:     newFireQueue(10),
+		positions: map[string]interface{}{},
+		trigger:   trig,
+		logger:    log.New(ioutil.Discard, "", 0),
+	}
+}
+
+func reduceInts(t *testing.T, p *Process, key string, vs ...int) {
+	for _, v := range vs {
+		err := p.reduce(graph.Event{Key: key, Data: v, Time: t0, Window: window.All().Apply(t0, v)[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// stored values of every window of the key.
+func stored(t *testing.T, p *Process, key string) []interface{} {
+	var found []interface{}
+	err := p.db.Drain(context.Background(), []string{key}, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		found = append(found, vs...)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return found
+}
+
+func TestDiscardFire(t *testing.T) {
+	count := trigger.AtCount(100).Discard()
+	p := newTestProcess(t, graph.New(), count)
+
+	var given []interface{}
+	fail := errors.New("failed")
+	var err error
+	p.sinks = []sink.Sink{funcsink.New(func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		if err != nil {
+			return err
+		}
+		given = append(given, vs...)
+		return nil
+	})}
+
+	// A failed fire puts back the windows, with values
+	// merged after them.
+	reduceInts(t, p, "key", 1, 2)
+	err = fail
+	if ferr := p.fire(p.sinks, &fire{key: "key"}); ferr != fail {
+		t.Fatalf("expected sink failure, found: %v", ferr)
+	}
+	reduceInts(t, p, "key", 3)
+	if vs := stored(t, p, "key"); len(vs) != 3 {
+		t.Fatalf("expected three stored values, found: %v", vs)
+	}
+
+	// A fire gives every value once, and resets the
+	// key, so values accumulate from scratch.
+	err = nil
+	if ferr := p.fire(p.sinks, &fire{key: "key"}); ferr != nil {
+		t.Fatal(ferr)
+	}
+	if len(given) != 3 {
+		t.Fatalf("expected three given values, found: %v", given)
+	}
+	if vs := stored(t, p, "key"); len(vs) != 0 {
+		t.Fatalf("expected key to be reset, found: %v", vs)
+	}
+
+	// The counts of the discarded windows are pruned.
+	data, cerr := count.Checkpoint()
+	if cerr != nil {
+		t.Fatal(cerr)
+	}
+	if string(data) != "null" {
+		t.Fatalf("expected no counts, found: %s", data)
+	}
+	reduceInts(t, p, "key", 4)
+	if vs := stored(t, p, "key"); len(vs) != 1 || vs[0] != 4 {
+		t.Fatalf("expected only the value after the reset, found: %v", vs)
+	}
+}
+
+func TestDiscardSpansFire(t *testing.T) {
+	p := newTestProcess(t, graph.New(), trigger.AtCount(100).Discard())
+
+	var given []interface{}
+	p.sinks = []sink.Sink{funcsink.New(func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		given = append(given, vs...)
+		return nil
+	})}
+
+	reduceInts(t, p, "key", 1, 2)
+	s := window.All().Apply(t0, nil)[0]
+	err := p.fire(p.sinks, &fire{key: "key", spans: []window.Span{s}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(given) != 2 {
+		t.Fatalf("expected two given values, found: %v", given)
+	}
+	if vs := stored(t, p, "key"); len(vs) != 0 {
+		t.Fatalf("expected window to be discarded, found: %v", vs)
+	}
+}
+
+func TestEmitCompactsClosed(t *testing.T) {
+	g := graph.New()
+	g.Window(window.Count(2))
+	p := newTestProcess(t, g, trigger.AtCount(100))
+
+	var given []window.Span
+	p.sinks = []sink.Sink{funcsink.New(func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		given = append(given, s)
+		return nil
+	})}
+
+	for _, v := range []int{1, 2, 3} {
+		err := p.reduce(graph.Event{Key: "key", Data: v, Time: t0, Window: window.Count(2).Apply(t0, v)[0]})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err := p.fire(p.sinks, &fire{key: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(given) != 2 {
+		t.Fatalf("expected closed and open windows, found: %v", given)
+	}
+
+	// The closed window is deleted once emitted, the
+	// open one takes more events.
+	if vs := stored(t, p, "key"); len(vs) != 1 || vs[0] != 3 {
+		t.Fatalf("expected only the open window, found: %v", vs)
+	}
+}
+
+func TestResetKeys(t *testing.T) {
+	marker := func(key string, v interface{}) bool {
+		return v == 0
+	}
+	resets := trigger.ResetKeys().When(marker)
+	p := newTestProcess(t, graph.New(), resets)
+
+	reduceInts(t, p, "key", 1, 2)
+	reduceInts(t, p, "other", 1)
+
+	// The reset of the trigger is the process's reset.
+	ctx, cancel := context.WithCancel(context.Background())
+	p.ctx = ctx
+	done := make(chan error, 1)
+	go func() {
+		done <- p.runTrig()
+	}()
+	reduceInts(t, p, "key", 0)
+
+	deadline := time.Now().Add(1 * time.Second)
+	for len(stored(t, p, "key")) != 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected key to be reset, found: %v", stored(t, p, "key"))
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if vs := stored(t, p, "other"); len(vs) != 1 {
+		t.Fatalf("expected other key to be kept, found: %v", vs)
+	}
+
+	// Stopping the run stops the trigger.
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCheckpointRestart(t *testing.T) {
+	count := trigger.AtCount(3)
+	p := newTestProcess(t, graph.New(), count)
+	reduceInts(t, p, "key", 1, 2)
+	p.fires.push([]*fire{{key: "pending"}})
+
+	err := p.checkpoint(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted process, over the same storage, has
+	// the count of the key and the pending fire.
+	restarted := trigger.AtCount(3)
+	r := newTestProcess(t, graph.New(), restarted)
+	r.db = p.db
+	err = r.restore()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs := r.fires.pending(); len(fs) != 1 || fs[0].key != "pending" {
+		t.Fatalf("expected pending fire, found: %v", fs)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	r.ctx = ctx
+	go r.runTrig()
+
+	reduceInts(t, r, "key", 3)
+	deadline := time.Now().Add(1 * time.Second)
+	for r.fires.len() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected key fired on its third event, found: %v", r.fires.pending())
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCheckpointSources(t *testing.T) {
+	p := newTestProcess(t, graph.New(), trigger.AtCount(3))
+	p.positions["lines"] = &linefile.Checkpoint{Name: "lines", Pos: 2, Offset: 10, Prefix: []byte{1}, PrefixLen: 10}
+
+	err := p.checkpoint(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted process resumes the source from
+	// the position of its last checkpoint.
+	r := newTestProcess(t, graph.New(), trigger.AtCount(3))
+	r.db = p.db
+	cp, err := r.sourceCheckpoint("lines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lcp, ok := cp.(*linefile.Checkpoint)
+	if !ok || lcp.Pos != 2 || lcp.Offset != 10 {
+		t.Fatalf("expected checkpoint of source, found: %v", cp)
+	}
+	cp, err = r.sourceCheckpoint("other")
+	if err != nil || cp != nil {
+		t.Fatalf("expected no checkpoint, found: %v, error: %v", cp, err)
+	}
+}
+
+func TestWatermarkOutOfOrder(t *testing.T) {
+	g := graph.New()
+	g.Window(window.Fixed(1 * time.Minute))
+	g.Retention(1 * time.Minute)
+	g.OutOfOrder(5 * time.Minute)
+	p := newTestProcess(t, g, trigger.AtCount(100))
+	p.ring, _ = schedule.New([]string{"a", "b"})
+	p.parent = "worker-a"
+
+	// The mapper's progress is behind its latest event
+	// time by the out-of-orderness allowed.
+	var sent []*msg.Progress
+	p.send = func(timeout time.Duration, receiver string, m interface{}) (interface{}, error) {
+		sent = append(sent, m.(*msg.Progress))
+		return nil, nil
+	}
+	p.mapped(t0.Add(20 * time.Minute))
+	p.mapped(t0.Add(15 * time.Minute))
+	if p.sendProgress(time.Time{}); len(sent) != 2 || !sent[0].MinTime().Equal(t0.Add(15*time.Minute)) {
+		t.Fatalf("expected progress sent to both reducers, found: %v", sent)
+	}
+
+	// The watermark is unknown until every mapper has
+	// progressed, then it is the one furthest behind.
+	if p.progressed("worker-a", sent[0].MinTime()) {
+		t.Fatal("expected no watermark")
+	}
+	if !p.progressed("worker-b", t0.Add(1*time.Minute)) || !p.watermarkOf().Equal(t0.Add(1*time.Minute)) {
+		t.Fatalf("expected watermark of mapper b, found: %v", p.watermarkOf())
+	}
+
+	// So events of mapper b behind mapper a are not
+	// late, and are kept.
+	err := p.reduce(graph.Event{Key: "key", Data: 1, Time: t0.Add(30 * time.Second), Window: window.NewSpan(t0, t0.Add(1*time.Minute))})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vs := stored(t, p, "key"); len(vs) != 1 {
+		t.Fatalf("expected event kept, found: %v", vs)
+	}
+}
+
+// request of a message, recording its response.
+type request struct {
+	msg      interface{}
+	response chan interface{}
+}
+
+func newRequest(m interface{}) *request {
+	return &request{msg: m, response: make(chan interface{}, 1)}
+}
+
+func (r *request) Msg() interface{}            { return r.msg }
+func (r *request) Context() context.Context    { return context.Background() }
+func (r *request) Ack() error                  { r.response <- nil; return nil }
+func (r *request) Respond(v interface{}) error { r.response <- v; return nil }
+
+func TestEndOfStream(t *testing.T) {
+	p := newTestProcess(t, graph.New(), trigger.WhenFinished())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.ctx = ctx
+	p.ended = make(chan struct{})
+	p.ring, _ = schedule.New([]string{"a"})
+
+	var given []interface{}
+	p.sinks = []sink.Sink{funcsink.New(func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		given = append(given, vs...)
+		return nil
+	})}
+	messages := make(chan grid.Request)
+	p.messages = messages
+
+	go p.runRed()
+	go p.runTrig()
+	go p.runFire()
+	go func() {
+		<-ctx.Done()
+		p.fires.close()
+	}()
+
+	reduceInts(t, p, "key", 1, 2)
+
+	// Let the trigger start, a trigger which is not yet
+	// started signals the end of stream once it is.
+	time.Sleep(100 * time.Millisecond)
+
+	// The end of stream is acked once the fires of
+	// the trigger are done.
+	req := newRequest(&msg.Progress{Graph: "type.name", Done: true})
+	messages <- req
+	select {
+	case v := <-req.response:
+		if v != nil {
+			t.Fatalf("expected ack, found: %v", v)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("expected end of stream acked")
+	}
+	if len(given) != 2 {
+		t.Fatalf("expected two given values, found: %v", given)
+	}
+
+	// A mapper which is done stops repeating its report
+	// once the end of stream arrived.
+	reports := 0
+	p.send = func(timeout time.Duration, receiver string, m interface{}) (interface{}, error) {
+		reports++
+		return nil, nil
+	}
+	err := p.report(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reports > 1 {
+		t.Fatalf("expected at most one report, found: %v", reports)
+	}
+}
+
+func TestReportTerm(t *testing.T) {
+	p := newTestProcess(t, graph.New(), trigger.WhenFinished())
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.ctx = ctx
+	p.ended = make(chan struct{})
+	p.ring, _ = schedule.New([]string{"a", "b"})
+	p.parent = "worker-a"
+	p.graphType = "type.v2"
+	p.graphName = "name"
+
+	// The done report names the graph, and the term
+	// the mapper shuffled on.
+	var sent *msg.Progress
+	p.send = func(timeout time.Duration, receiver string, m interface{}) (interface{}, error) {
+		sent = m.(*msg.Progress)
+		cancel()
+		return nil, nil
+	}
+	err := p.report(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sent == nil || sent.GraphType != "type.v2" || sent.GraphName != "name" {
+		t.Fatalf("expected report of graph type.v2 name, found: %v", sent)
+	}
+	if !reflect.DeepEqual(sent.Term, []string{"worker-a", "worker-b"}) {
+		t.Fatalf("expected term of workers a and b, found: %v", sent.Term)
+	}
+}
+
+func TestFinishedMappersKeepWindows(t *testing.T) {
+	g := graph.New()
+	g.Window(window.Fixed(1 * time.Minute))
+	g.Retention(1 * time.Minute)
+	g.OutOfOrder(5 * time.Minute)
+	p := newTestProcess(t, g, trigger.WhenFinished())
+	p.ring, _ = schedule.New([]string{"a", "b"})
+	p.parent = "worker-a"
+
+	var sent []*msg.Progress
+	p.send = func(timeout time.Duration, receiver string, m interface{}) (interface{}, error) {
+		sent = append(sent, m.(*msg.Progress))
+		return nil, nil
+	}
+	var given []interface{}
+	p.sinks = []sink.Sink{funcsink.New(func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		given = append(given, vs...)
+		return nil
+	})}
+
+	last := t0.Add(20 * time.Minute)
+	err := p.reduce(graph.Event{Key: "key", Data: 1, Time: last, Window: window.NewSpan(last, last.Add(1*time.Minute))})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A finished mapper's progress is its latest event
+	// time, not the out-of-orderness behind it, nor any
+	// time after it.
+	p.mapped(last)
+	p.finished()
+	if p.sendProgress(time.Time{}); len(sent) != 2 || !sent[0].MinTime().Equal(last) {
+		t.Fatalf("expected progress of latest event time, found: %v", sent)
+	}
+	p.progressed("worker-a", sent[0].MinTime())
+	p.progressed("worker-b", last)
+
+	// Once every mapper finished, compaction keeps the
+	// windows the end of stream emits.
+	expiry, ok := p.expiry()
+	if !ok || !expiry.Equal(last.Add(-1*time.Minute)) {
+		t.Fatalf("expected expiry behind latest event time, found: %v", expiry)
+	}
+	err = p.db.Compact(context.Background(), expiry)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = p.fire(p.sinks, &fire{key: "key"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(given) != 1 {
+		t.Fatalf("expected window emitted, found: %v", given)
+	}
+}
+
+func TestCountMergedNotNested(t *testing.T) {
+	// Nested windows assigned to each event are counted
+	// apart.
+	g := graph.New()
+	w := window.Assign(func(ts time.Time, v interface{}) []window.Span {
+		hour := ts.Truncate(time.Hour)
+		day := ts.Truncate(24 * time.Hour)
+		return []window.Span{window.NewSpan(hour, hour.Add(time.Hour)), window.NewSpan(day, day.Add(24*time.Hour))}
+	})
+	g.Window(w)
+	count := trigger.AtCount(3)
+	p := newTestProcess(t, g, count)
+	for i := 0; i < 2; i++ {
+		for _, s := range w.Apply(t0, i) {
+			err := p.reduce(graph.Event{Key: "key", Data: i, Time: t0, Window: s})
+			if err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	data, err := count.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(string(data), `"n":2`) != 2 {
+		t.Fatalf("expected counts of both windows, found: %s", data)
+	}
+
+	// Sessions merged by the window carry their counts.
+	g = graph.New()
+	g.Window(window.Session(90 * time.Second))
+	count = trigger.AtCount(3)
+	p = newTestProcess(t, g, count)
+	for i, ts := range []time.Time{t0, t0.Add(2 * time.Minute), t0.Add(1 * time.Minute)} {
+		err := p.reduce(graph.Event{Key: "key", Data: i, Time: ts, Window: window.NewSpan(ts, ts.Add(90*time.Second))})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	var kss []trigger.KeySpan
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		count.Stop()
+	}()
+	count.StartSpans(func(signaled []trigger.KeySpan) error {
+		kss = signaled
+		cancel()
+		return nil
+	})
+	if len(kss) != 1 || kss[0].Span != window.NewSpan(t0, t0.Add(210*time.Second)) {
+		t.Fatalf("expected merged session signaled, found: %v", kss)
+	}
+}
+
+func TestResetKeysMessage(t *testing.T) {
+	p := newTestProcess(t, graph.New(), trigger.AtCount(100))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p.ctx = ctx
+	messages := make(chan grid.Request)
+	p.messages = messages
+	go p.runRed()
+
+	reduceInts(t, p, "key", 1, 2)
+	reduceInts(t, p, "other", 1)
+
+	// Keys are reset on request of the leader, keys
+	// the process does not hold are ignored.
+	req := newRequest(&msg.ResetKeys{GraphType: "type", GraphName: "name", Keys: []string{"key", "unknown"}})
+	messages <- req
+	if v := <-req.response; v != nil {
+		t.Fatalf("expected ack, found: %v", v)
+	}
+	if vs := stored(t, p, "key"); len(vs) != 0 {
+		t.Fatalf("expected key to be reset, found: %v", vs)
+	}
+	if vs := stored(t, p, "other"); len(vs) != 1 {
+		t.Fatalf("expected other key to be kept, found: %v", vs)
+	}
+}
@@ -2,16 +2,81 @@ package mapred
 
 import (
 	"github.com/lytics/flo/graph"
+	"github.com/lytics/flo/trigger"
 	"github.com/lytics/flo/window"
 )
 
 func (p *Process) reduce(e graph.Event) error {
+	// Drop late events for windows which have
+	// expired, they would otherwise recreate
+	// windows already garbage collected.
+	expiry, ok := p.expiry()
+	if ok && e.Window.Kind() == window.Time && e.Window.End().Before(expiry) {
+		p.logger.Printf("dropping late event for expired window: %v, key: %v", e.Window, e.Key)
+		return nil
+	}
+
+	// Windows assembled from panes are only assembled
+	// for triggers which inspect their values.
+	inspecting := false
+	if i, ok := p.trigger.(trigger.Inspector); ok {
+		inspecting = i.Inspecting()
+	}
+
 	mut := func(state window.State) error {
-		err := p.def.Merge(e.Window, e.Data, state)
+		m := &merging{State: state}
+		err := p.def.Merge(e.Window, e.Data, m)
+		if err != nil {
+			return err
+		}
+		m.signal(e.Key, p.trigger)
+		// Only the windows holding the event are given
+		// to the trigger, reading every window of the
+		// key would defeat lazy reads.
+		vs, err := p.def.Windows(e.Window, state, inspecting)
 		if err != nil {
 			return err
 		}
-		return p.def.Trigger().Modified(e.Key, e.Data, state.Windows())
+		return p.trigger.Modified(e.Key, e.Data, vs)
 	}
 	return p.db.Apply(p.ctx, e.Key, mut)
 }
+
+// merging state, recording the windows deleted and set by
+// the merge of an event.
+type merging struct {
+	window.State
+	deleted []window.Span
+	set     []window.Span
+}
+
+func (m *merging) Del(s window.Span) {
+	m.deleted = append(m.deleted, s)
+	m.State.Del(s)
+}
+
+func (m *merging) Set(s window.Span, vs []interface{}) {
+	m.set = append(m.set, s)
+	m.State.Set(s, vs)
+}
+
+// signal the trigger of the key, if it keeps state per
+// window, when the merge deleted windows and set one,
+// which they were merged into. Windows which only set,
+// such as nested windows, merged nothing.
+func (m *merging) signal(key string, t trigger.Trigger) {
+	mt, ok := t.(trigger.Merger)
+	if !ok || len(m.deleted) == 0 || len(m.set) != 1 {
+		return
+	}
+	into := m.set[0]
+	from := make([]window.Span, 0, len(m.deleted))
+	for _, s := range m.deleted {
+		if s != into {
+			from = append(from, s)
+		}
+	}
+	if len(from) > 0 {
+		mt.Merged(key, from, into)
+	}
+}
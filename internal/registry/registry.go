@@ -43,6 +43,7 @@ type Registration struct {
 	Type   string `json:"type"`
 	Name   string `json:"name"`
 	Wanted string `json:"wanted"`
+	Actual string `json:"actual,omitempty"`
 	Config string `json:"config"`
 }
 
@@ -54,8 +55,8 @@ func (r *Registration) UnmarshalConfig() ([]byte, error) {
 
 // String descritpion of registration.
 func (r *Registration) String() string {
-	return fmt.Sprintf("name: %v, type: %v, wanted: %v, config size: %v",
-		r.Type, r.Name, r.Wanted, len(r.Config))
+	return fmt.Sprintf("name: %v, type: %v, wanted: %v, actual: %v, config size: %v",
+		r.Type, r.Name, r.Wanted, r.Actual, len(r.Config))
 }
 
 // EventType of a watch event.
@@ -235,6 +236,9 @@ const (
 	Stopping State = "stopping"
 	// Terminating state, drop everything on the floor stop.
 	Terminating State = "terminating"
+	// Completed state, every source reached its end and
+	// the final windows were emitted.
+	Completed State = "completed"
 )
 
 // Insert the graph entry.
@@ -333,10 +337,46 @@ func (rr *Registry) SetWanted(ctx context.Context, graphType, graphName string,
 	if err != nil {
 		return err
 	}
-	if rec.Wanted == string(state) {
+	if rec.Wanted == string(state) && rec.Actual == "" {
 		return nil
 	}
+	// A newly wanted state makes the actual one stale,
+	// for example running a completed graph again.
 	rec.Wanted = string(state)
+	rec.Actual = ""
+
+	return rr.insert(ctx, getRes.Kvs[0].Version, key, rec)
+}
+
+// SetActual state of the graph under the given namespace.
+func (rr *Registry) SetActual(ctx context.Context, graphType, graphName string, state State) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	key := rr.keyFromGraphTypeAndName(graphType, graphName)
+
+	getRes, err := rr.kv.Get(ctx, key, etcdv3.WithLimit(2))
+	if err != nil {
+		return err
+	}
+
+	if getRes.Count == 0 {
+		return nil
+	}
+	if getRes.Count > 1 {
+		return ErrMultipleValues
+	}
+
+	kv := getRes.Kvs[0]
+	rec := &Registration{}
+	err = json.Unmarshal(kv.Value, rec)
+	if err != nil {
+		return err
+	}
+	if rec.Actual == string(state) {
+		return nil
+	}
+	rec.Actual = string(state)
 
 	return rr.insert(ctx, getRes.Kvs[0].Version, key, rec)
 }
@@ -134,6 +134,57 @@ func TestSetWanted(t *testing.T) {
 	}
 }
 
+func TestSetActual(t *testing.T) {
+	client, r, etcdcleanup := bootstrap(t)
+	defer etcdcleanup()
+	defer client.Close()
+
+	// Insert.
+	timeout, cancel := timeoutContext()
+	err := r.Insert(timeout, testGraphType, testGraphName, Running, []byte(testConfig))
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Set actual state.
+	timeout, cancel = timeoutContext()
+	err = r.SetActual(timeout, testGraphType, testGraphName, Completed)
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Select to confirm new state.
+	timeout, cancel = timeoutContext()
+	reg, err := r.Select(timeout, testGraphType, testGraphName)
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg.Actual != string(Completed) {
+		t.Fatal("expected 'completed' actual state")
+	}
+
+	// Wanting running again clears the actual state.
+	timeout, cancel = timeoutContext()
+	err = r.SetWanted(timeout, testGraphType, testGraphName, Running)
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	timeout, cancel = timeoutContext()
+	reg, err = r.Select(timeout, testGraphType, testGraphName)
+	cancel()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reg.Actual != "" {
+		t.Fatal("expected empty actual state")
+	}
+}
+
 func TestWatch(t *testing.T) {
 	client, r, etcdcleanup := bootstrap(t)
 	defer etcdcleanup()
@@ -60,6 +60,30 @@ func (r *Ring) Reducer(key, graphType, graphName string) string {
 	return fmt.Sprintf("worker-%v-%v-%v", peer, graphType, graphName)
 }
 
+// Workers of the ring, each once, which are the parents
+// of the graph's mappers.
+func (r *Ring) Workers() []string {
+	seen := map[string]bool{}
+	var workers []string
+	for _, peer := range r.peers {
+		if !seen[peer] {
+			seen[peer] = true
+			workers = append(workers, fmt.Sprintf("worker-%v", peer))
+		}
+	}
+	sort.Strings(workers)
+	return workers
+}
+
+// Reducers of a specific graph, each once.
+func (r *Ring) Reducers(graphType, graphName string) []string {
+	var reducers []string
+	for _, worker := range r.Workers() {
+		reducers = append(reducers, fmt.Sprintf("%v-%v-%v", worker, graphType, graphName))
+	}
+	return reducers
+}
+
 func (r *Ring) String() string {
 	sorted := []uint64{}
 	for k := range r.peers {
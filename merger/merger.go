@@ -3,6 +3,21 @@ package merger
 // Merger for merging a and b into something new.
 type Merger func(a, b interface{}) (interface{}, error)
 
+// Mergers create the merger of each graph instance, for
+// mergers which keep state, or are configured by the
+// instance's conf.
+type Mergers interface {
+	Setup(graphType, graphName string, conf []byte) (Merger, error)
+}
+
+// SetupFunc creates the merger of a graph instance.
+type SetupFunc func(graphType, graphName string, conf []byte) (Merger, error)
+
+// Setup the merger by calling f.
+func (f SetupFunc) Setup(graphType, graphName string, conf []byte) (Merger, error) {
+	return f(graphType, graphName, conf)
+}
+
 // ManyMerger is like merge but for values from two
 // slices which need to be merged.
 type ManyMerger func(as, bs []interface{}) ([]interface{}, error)
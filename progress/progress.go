@@ -13,5 +13,7 @@ type SourceDone struct {
 }
 
 type Heuristic struct {
-	EOS bool
+	EOS       bool
+	Watermark time.Time // Event time every mapper has passed, zero when unknown.
+	Expiry    time.Time // Time windows ending before it are expired, zero when kept forever.
 }
@@ -7,10 +7,12 @@ import (
 	"net"
 	"os"
 	"sync"
+	"time"
 
 	etcdv3 "github.com/coreos/etcd/clientv3"
 	"github.com/lytics/flo/internal/actor/leader"
 	"github.com/lytics/flo/internal/actor/worker"
+	"github.com/lytics/flo/internal/msg"
 	"github.com/lytics/flo/internal/registry"
 	"github.com/lytics/flo/storage"
 	"github.com/lytics/grid"
@@ -57,7 +59,13 @@ func NewServer(etcd *etcdv3.Client, cfg Cfg) (*Server, error) {
 	s.registry = reg
 
 	open := func(name string) (*storage.DB, error) {
-		return storage.Open(fmt.Sprintf("%v-%v", cfg.Namespace, name), cfg.Driver)
+		driverCfg := cfg.Driver
+		if cfg.Cache != nil {
+			cacheCfg := *cfg.Cache
+			cacheCfg.Backing = cfg.Driver
+			driverCfg = cacheCfg
+		}
+		return storage.Open(fmt.Sprintf("%v-%v", cfg.Namespace, name), driverCfg)
 	}
 
 	send := client.Request
@@ -89,7 +97,8 @@ func NewServer(etcd *etcdv3.Client, cfg Cfg) (*Server, error) {
 			leader.Listen(listen),
 			leader.Watch(watch),
 			leader.Peers(peers),
-			leader.Mailboxes(mailboxes))
+			leader.Mailboxes(mailboxes),
+			leader.SetActual(reg.SetActual))
 	})
 
 	server.RegisterDef("worker", func([]byte) (grid.Actor, error) {
@@ -124,3 +133,15 @@ func (s *Server) Serve(lis net.Listener) error {
 func (s *Server) Stop() {
 	s.server.Stop()
 }
+
+// ResetKeys of the running graph of the given type and
+// name, deleting the windows of each key, as a trigger
+// resetting them would. The leader must be running.
+func (s *Server) ResetKeys(graphType, graphName string, keys ...string) error {
+	_, err := s.client.Request(time.Minute, "leader", &msg.ResetKeys{
+		GraphType: graphType,
+		GraphName: graphName,
+		Keys:      keys,
+	})
+	return err
+}
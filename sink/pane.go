@@ -0,0 +1,58 @@
+package sink
+
+import (
+	"context"
+	"fmt"
+)
+
+// Timing of a pane, relative to the watermark passing
+// the end of its window.
+type Timing int
+
+const (
+	// Early pane, speculative, fired while the
+	// window is still open.
+	Early Timing = 0
+	// OnTime pane, fired when the watermark passes
+	// the end of the window.
+	OnTime Timing = 1
+	// Late pane, refined by events arriving after
+	// the watermark passed the end of the window.
+	Late Timing = 2
+)
+
+func (t Timing) String() string {
+	switch t {
+	case Early:
+		return "early"
+	case OnTime:
+		return "on time"
+	case Late:
+		return "late"
+	default:
+		return fmt.Sprintf("Timing(%d)", int(t))
+	}
+}
+
+// Pane of a window, describing which firing of the
+// window is given to the sink, for triggers which fire
+// a window several times.
+type Pane struct {
+	Timing Timing
+	Index  int  // Index of the firing, from zero.
+	Final  bool // Final firing of the window.
+}
+
+type paneKey struct{}
+
+// WithPane returns a context carrying the pane.
+func WithPane(ctx context.Context, p Pane) context.Context {
+	return context.WithValue(ctx, paneKey{}, p)
+}
+
+// PaneOf the window given to a sink, false when the
+// trigger does not describe panes.
+func PaneOf(ctx context.Context) (Pane, bool) {
+	p, ok := ctx.Value(paneKey{}).(Pane)
+	return p, ok
+}
@@ -19,6 +19,8 @@ type Sink interface {
 	// Stop the sink and clean up. Stop is only
 	// called if Init has been called.
 	Stop() error
-	// Give key and values to sink.
+	// Give key and values to sink. The pane of the
+	// window, when the trigger describes panes, is
+	// carried by ctx, see PaneOf.
 	Give(ctx context.Context, w window.Span, key string, vs []interface{}) error
 }
@@ -0,0 +1,12 @@
+package source
+
+import "errors"
+
+var (
+	// ErrTruncated is returned by file sources resumed from
+	// a checkpoint beyond the end of the file.
+	ErrTruncated = errors.New("source: file truncated since checkpoint")
+	// ErrReplaced is returned by file sources resumed from
+	// a checkpoint of a different file of the same name.
+	ErrReplaced = errors.New("source: file replaced since checkpoint")
+)
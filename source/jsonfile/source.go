@@ -5,10 +5,13 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"sync"
 
+	"github.com/lytics/flo/internal/codec"
+	"github.com/lytics/flo/internal/fileid"
 	"github.com/lytics/flo/source"
 )
 
@@ -27,10 +30,17 @@ func New(prototype interface{}, name string) *Source {
 	}
 }
 
+func init() {
+	codec.Register(Checkpoint{})
+}
+
 // Source from JSON encoded values.
 type Source struct {
 	mu        sync.Mutex
 	pos       int
+	base      int64
+	prefix    []byte
+	plen      int64
 	f         *os.File
 	stream    *json.Decoder
 	meta      source.Metadata
@@ -72,9 +82,31 @@ func (s *Source) Init(ctx context.Context, checkpoint interface{}) error {
 		return err
 	}
 	s.f = f
+
+	// Checkpoints without a prefix predate offsets, so
+	// the records before them are read and discarded.
+	if ok && cp.Prefix != nil {
+		err := fileid.Check(s.f, cp.Offset, cp.Prefix, cp.PrefixLen)
+		if err != nil {
+			s.close()
+			return err
+		}
+		_, err = s.f.Seek(cp.Offset, io.SeekStart)
+		if err != nil {
+			s.close()
+			return err
+		}
+		s.pos = int(cp.Pos)
+		s.base = cp.Offset
+	}
+	s.prefix, s.plen, err = fileid.Prefix(s.f)
+	if err != nil {
+		s.close()
+		return err
+	}
 	s.stream = json.NewDecoder(bufio.NewReader(s.f))
 
-	if ok {
+	if ok && cp.Prefix == nil {
 		for int64(s.pos) < cp.Pos {
 			_, err := s.take(ctx)
 			if err != nil {
@@ -91,6 +123,11 @@ func (s *Source) Stop() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.close()
+}
+
+// close without locking.
+func (s *Source) close() error {
 	if s.f == nil {
 		return nil
 	}
@@ -117,14 +154,21 @@ func (s *Source) Take(ctx context.Context) (*source.Item, error) {
 
 // take without locking.
 func (s *Source) take(ctx context.Context) (*source.Item, error) {
+	// The offset of the record is where the last one
+	// ended, the decoder skips the space in between.
+	offset := s.base + s.stream.InputOffset()
+
 	v := reflect.New(reflect.TypeOf(s.prototype)).Interface()
 	if err := s.stream.Decode(v); err != nil {
 		return nil, err
 	}
 
 	item := source.NewItem(v, &Checkpoint{
-		Name: s.meta.Name,
-		Pos:  int64(s.pos),
+		Name:      s.meta.Name,
+		Pos:       int64(s.pos),
+		Offset:    offset,
+		Prefix:    s.prefix,
+		PrefixLen: s.plen,
 	}, nil)
 
 	s.pos++
@@ -0,0 +1,151 @@
+package jsonfile
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/lytics/flo/source"
+)
+
+type record struct {
+	N int
+}
+
+// write the records to a file in a temporary directory,
+// which is removed by the returned function.
+func write(t *testing.T, records string) (string, func()) {
+	dir, err := ioutil.TempDir("", "flo-jsonfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := path.Join(dir, "records")
+	err = ioutil.WriteFile(name, []byte(records), 0644)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return name, func() { os.RemoveAll(dir) }
+}
+
+// take every remaining record, and the checkpoint of
+// the first.
+func take(t *testing.T, s *Source) ([]int, *Checkpoint) {
+	var ns []int
+	var first *Checkpoint
+	for {
+		item, err := s.Take(context.Background())
+		if err == io.EOF {
+			return ns, first
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = item.Checkpoint().(*Checkpoint)
+		}
+		ns = append(ns, item.Value().(*record).N)
+	}
+}
+
+// checkpointOf the n-th record.
+func checkpointOf(t *testing.T, name string, n int) *Checkpoint {
+	s := New(record{}, name)
+	err := s.Init(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	for i := 0; ; i++ {
+		item, err := s.Take(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == n {
+			return item.Checkpoint().(*Checkpoint)
+		}
+	}
+}
+
+func TestResume(t *testing.T) {
+	name, cleanup := write(t, `{"N":1} {"N":2}`+"\n"+`{"N":3}`)
+	defer cleanup()
+
+	cp := checkpointOf(t, name, 1)
+	if cp.Pos != 1 || cp.Offset != 7 || cp.Prefix == nil {
+		t.Fatalf("expected checkpoint of second record, found: %v", cp)
+	}
+
+	s := New(record{}, name)
+	err := s.Init(context.Background(), cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	ns, first := take(t, s)
+	if len(ns) != 2 || ns[0] != 2 || ns[1] != 3 {
+		t.Fatalf("expected records 2 and 3, found: %v", ns)
+	}
+	if first.Pos != 1 || first.Offset != 7 {
+		t.Fatalf("expected positions to continue, found: %v", first)
+	}
+}
+
+func TestResumeLegacy(t *testing.T) {
+	name, cleanup := write(t, `{"N":1} {"N":2} {"N":3}`)
+	defer cleanup()
+
+	// Checkpoints without a prefix only have the
+	// position, the records before it are skipped.
+	s := New(record{}, name)
+	err := s.Init(context.Background(), &Checkpoint{Name: name, Pos: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	ns, first := take(t, s)
+	if len(ns) != 1 || ns[0] != 3 {
+		t.Fatalf("expected record 3, found: %v", ns)
+	}
+	if first.Pos != 2 || first.Prefix == nil {
+		t.Fatalf("expected checkpoint with prefix, found: %v", first)
+	}
+}
+
+func TestResumeTruncated(t *testing.T) {
+	name, cleanup := write(t, `{"N":1} {"N":2} {"N":3}`)
+	defer cleanup()
+
+	cp := checkpointOf(t, name, 2)
+	err := ioutil.WriteFile(name, []byte(`{"N":1}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(record{}, name)
+	err = s.Init(context.Background(), cp)
+	if err != source.ErrTruncated {
+		t.Fatalf("expected truncated, found: %v", err)
+	}
+}
+
+func TestResumeReplaced(t *testing.T) {
+	name, cleanup := write(t, `{"N":1} {"N":2} {"N":3}`)
+	defer cleanup()
+
+	cp := checkpointOf(t, name, 1)
+	err := ioutil.WriteFile(name, []byte(`{"N":7} {"N":8} {"N":9}`), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := New(record{}, name)
+	err = s.Init(context.Background(), cp)
+	if err != source.ErrReplaced {
+		t.Fatalf("expected replaced, found: %v", err)
+	}
+}
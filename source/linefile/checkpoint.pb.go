@@ -29,8 +29,11 @@ var _ = math.Inf
 const _ = proto.ProtoPackageIsVersion2 // please upgrade the proto package
 
 type Checkpoint struct {
-	Pos  int64  `protobuf:"varint,2,opt,name=Pos" json:"Pos,omitempty"`
-	Name string `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
+	Pos       int64  `protobuf:"varint,2,opt,name=Pos" json:"Pos,omitempty"`
+	Name      string `protobuf:"bytes,1,opt,name=Name" json:"Name,omitempty"`
+	Offset    int64  `protobuf:"varint,3,opt,name=Offset" json:"Offset,omitempty"`
+	Prefix    []byte `protobuf:"bytes,4,opt,name=Prefix,proto3" json:"Prefix,omitempty"`
+	PrefixLen int64  `protobuf:"varint,5,opt,name=PrefixLen" json:"PrefixLen,omitempty"`
 }
 
 func (m *Checkpoint) Reset()                    { *m = Checkpoint{} }
@@ -52,6 +55,27 @@ func (m *Checkpoint) GetName() string {
 	return ""
 }
 
+func (m *Checkpoint) GetOffset() int64 {
+	if m != nil {
+		return m.Offset
+	}
+	return 0
+}
+
+func (m *Checkpoint) GetPrefix() []byte {
+	if m != nil {
+		return m.Prefix
+	}
+	return nil
+}
+
+func (m *Checkpoint) GetPrefixLen() int64 {
+	if m != nil {
+		return m.PrefixLen
+	}
+	return 0
+}
+
 func init() {
 	proto.RegisterType((*Checkpoint)(nil), "linefile.Checkpoint")
 }
@@ -59,12 +83,14 @@ func init() {
 func init() { proto.RegisterFile("checkpoint.proto", fileDescriptor0) }
 
 var fileDescriptor0 = []byte{
-	// 97 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x09, 0x6e, 0x88, 0x02, 0xff, 0xe2, 0x12, 0x48, 0xce, 0x48, 0x4d,
+	// 137 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe3, 0x12, 0x48, 0xce, 0x48, 0x4d,
 	0xce, 0x2e, 0xc8, 0xcf, 0xcc, 0x2b, 0xd1, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0xe2, 0xc8, 0xc9,
-	0xcc, 0x4b, 0x4d, 0xcb, 0xcc, 0x49, 0x55, 0x32, 0xe2, 0xe2, 0x72, 0x86, 0xcb, 0x0a, 0x09, 0x70,
-	0x31, 0x07, 0xe4, 0x17, 0x4b, 0x30, 0x29, 0x30, 0x6a, 0x30, 0x07, 0x81, 0x98, 0x42, 0x42, 0x5c,
-	0x2c, 0x7e, 0x89, 0xb9, 0xa9, 0x12, 0x8c, 0x0a, 0x8c, 0x1a, 0x9c, 0x41, 0x60, 0x76, 0x12, 0x1b,
-	0xd8, 0x10, 0x63, 0x40, 0x00, 0x00, 0x00, 0xff, 0xff, 0x89, 0xb6, 0x71, 0x0e, 0x58, 0x00, 0x00,
-	0x00,
+	0xcc, 0x4b, 0x4d, 0xcb, 0xcc, 0x49, 0x55, 0x6a, 0x60, 0xe4, 0xe2, 0x72, 0x86, 0x4b, 0x0b, 0x09,
+	0x70, 0x31, 0x07, 0xe4, 0x17, 0x4b, 0x30, 0x29, 0x30, 0x6a, 0x30, 0x07, 0x81, 0x98, 0x42, 0x42,
+	0x5c, 0x2c, 0x7e, 0x89, 0xb9, 0xa9, 0x12, 0x8c, 0x40, 0x21, 0xce, 0x20, 0x30, 0x5b, 0x48, 0x8c,
+	0x8b, 0xcd, 0x3f, 0x2d, 0xad, 0x38, 0xb5, 0x44, 0x82, 0x19, 0xac, 0x10, 0xca, 0x03, 0x89, 0x07,
+	0x14, 0x01, 0xcd, 0xad, 0x90, 0x60, 0x01, 0x8a, 0xf3, 0x04, 0x41, 0x79, 0x42, 0x32, 0x5c, 0x9c,
+	0x10, 0x96, 0x4f, 0x6a, 0x9e, 0x04, 0x2b, 0x58, 0x0b, 0x42, 0x20, 0x89, 0x0d, 0xec, 0x26, 0x63,
+	0x00, 0x34, 0x33, 0x86, 0x8b, 0xa7, 0x00, 0x00, 0x00,
 }
@@ -4,9 +4,12 @@ import (
 	"bufio"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"sync"
 
+	"github.com/lytics/flo/internal/codec"
+	"github.com/lytics/flo/internal/fileid"
 	"github.com/lytics/flo/source"
 )
 
@@ -20,13 +23,20 @@ func FromFile(name string) *Source {
 	}
 }
 
+func init() {
+	codec.Register(Checkpoint{})
+}
+
 // Source of data.
 type Source struct {
-	mu   sync.Mutex
-	f    *os.File
-	r    *bufio.Reader
-	pos  int
-	meta source.Metadata
+	mu     sync.Mutex
+	f      *os.File
+	r      *bufio.Reader
+	pos    int
+	offset int64
+	prefix []byte
+	plen   int64
+	meta   source.Metadata
 }
 
 // Metadata about the source.
@@ -61,9 +71,31 @@ func (s *Source) Init(ctx context.Context, checkpoint interface{}) error {
 		return err
 	}
 	s.f = f
+
+	// Checkpoints without a prefix predate offsets, so
+	// the records before them are read and discarded.
+	if ok && cp.Prefix != nil {
+		err := fileid.Check(s.f, cp.Offset, cp.Prefix, cp.PrefixLen)
+		if err != nil {
+			s.close()
+			return err
+		}
+		_, err = s.f.Seek(cp.Offset, io.SeekStart)
+		if err != nil {
+			s.close()
+			return err
+		}
+		s.pos = int(cp.Pos)
+		s.offset = cp.Offset
+	}
+	s.prefix, s.plen, err = fileid.Prefix(s.f)
+	if err != nil {
+		s.close()
+		return err
+	}
 	s.r = bufio.NewReader(s.f)
 
-	if ok {
+	if ok && cp.Prefix == nil {
 		for int64(s.pos) < cp.Pos {
 			_, err := s.take(ctx)
 			if err != nil {
@@ -80,6 +112,11 @@ func (s *Source) Stop() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	return s.close()
+}
+
+// close without locking.
+func (s *Source) close() error {
 	if s.f == nil {
 		return nil
 	}
@@ -112,11 +149,15 @@ func (s *Source) take(ctx context.Context) (*source.Item, error) {
 	}
 
 	item := source.NewItem(v, &Checkpoint{
-		Name: s.meta.Name,
-		Pos:  int64(s.pos),
+		Name:      s.meta.Name,
+		Pos:       int64(s.pos),
+		Offset:    s.offset,
+		Prefix:    s.prefix,
+		PrefixLen: s.plen,
 	}, nil)
 
 	s.pos++
+	s.offset += int64(len(v))
 
 	return item, nil
 }
@@ -0,0 +1,156 @@
+package linefile
+
+import (
+	"context"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/lytics/flo/source"
+)
+
+// write the lines to a file in a temporary directory,
+// which is removed by the returned function.
+func write(t *testing.T, lines string) (string, func()) {
+	dir, err := ioutil.TempDir("", "flo-linefile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	name := path.Join(dir, "lines")
+	err = ioutil.WriteFile(name, []byte(lines), 0644)
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return name, func() { os.RemoveAll(dir) }
+}
+
+// take every remaining line, and the checkpoint of
+// the first.
+func take(t *testing.T, s *Source) ([]string, *Checkpoint) {
+	var lines []string
+	var first *Checkpoint
+	for {
+		item, err := s.Take(context.Background())
+		if err == io.EOF {
+			return lines, first
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if first == nil {
+			first = item.Checkpoint().(*Checkpoint)
+		}
+		lines = append(lines, item.Value().(string))
+	}
+}
+
+// checkpointOf the n-th line.
+func checkpointOf(t *testing.T, name string, n int) *Checkpoint {
+	s := FromFile(name)
+	err := s.Init(context.Background(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+
+	for i := 0; ; i++ {
+		item, err := s.Take(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if i == n {
+			return item.Checkpoint().(*Checkpoint)
+		}
+	}
+}
+
+func TestResume(t *testing.T) {
+	name, cleanup := write(t, "a\nb\nc\n")
+	defer cleanup()
+
+	cp := checkpointOf(t, name, 1)
+	if cp.Pos != 1 || cp.Offset != 2 || cp.Prefix == nil {
+		t.Fatalf("expected checkpoint of second line, found: %v", cp)
+	}
+
+	// Resuming seeks to the line of the checkpoint,
+	// including lines appended since.
+	f, err := os.OpenFile(name, os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.WriteString("d\n")
+	f.Close()
+
+	s := FromFile(name)
+	err = s.Init(context.Background(), cp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	lines, first := take(t, s)
+	if len(lines) != 3 || lines[0] != "b\n" || lines[2] != "d\n" {
+		t.Fatalf("expected lines b, c and d, found: %q", lines)
+	}
+	if first.Pos != 1 || first.Offset != 2 {
+		t.Fatalf("expected positions to continue, found: %v", first)
+	}
+}
+
+func TestResumeLegacy(t *testing.T) {
+	name, cleanup := write(t, "a\nb\nc\n")
+	defer cleanup()
+
+	// Checkpoints without a prefix only have the
+	// position, the lines before it are skipped.
+	s := FromFile(name)
+	err := s.Init(context.Background(), &Checkpoint{Name: name, Pos: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Stop()
+	lines, first := take(t, s)
+	if len(lines) != 1 || lines[0] != "c\n" {
+		t.Fatalf("expected line c, found: %q", lines)
+	}
+	if first.Pos != 2 || first.Offset != 4 || first.Prefix == nil {
+		t.Fatalf("expected checkpoint with offset, found: %v", first)
+	}
+}
+
+func TestResumeTruncated(t *testing.T) {
+	name, cleanup := write(t, "a\nb\nc\n")
+	defer cleanup()
+
+	cp := checkpointOf(t, name, 2)
+	err := ioutil.WriteFile(name, []byte("a\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := FromFile(name)
+	err = s.Init(context.Background(), cp)
+	if err != source.ErrTruncated {
+		t.Fatalf("expected truncated, found: %v", err)
+	}
+}
+
+func TestResumeReplaced(t *testing.T) {
+	name, cleanup := write(t, "a\nb\nc\n")
+	defer cleanup()
+
+	cp := checkpointOf(t, name, 1)
+	err := ioutil.WriteFile(name, []byte("x\ny\nz\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := FromFile(name)
+	err = s.Init(context.Background(), cp)
+	if err != source.ErrReplaced {
+		t.Fatalf("expected replaced, found: %v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/lytics/flo/graph"
 	"github.com/lytics/flo/internal/codec"
+	"github.com/lytics/flo/storage"
 	"github.com/lytics/flo/storage/driver"
 )
 
@@ -12,6 +13,10 @@ import (
 type Cfg struct {
 	Driver    driver.Cfg
 	Namespace string
+	// Cache of rows in front of the driver, nil for
+	// none. Its Backing is set to the Driver. Cached
+	// writes are made durable with each checkpoint.
+	Cache *storage.CacheCfg
 }
 
 var (
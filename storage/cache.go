@@ -0,0 +1,409 @@
+package storage
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/window"
+)
+
+const (
+	// defaultCacheKeys is used when the cache
+	// configuration does not define MaxKeys.
+	defaultCacheKeys = 10000
+	// spanBytes estimated for each cached window,
+	// besides its values.
+	spanBytes = 64
+	// valueBytes estimated for values whose size
+	// is not known.
+	valueBytes = 64
+)
+
+// CacheCfg wraps the configuration of any driver with an
+// in-memory LRU write-back cache of decoded rows. Writes
+// to a row are coalesced in memory and written back to
+// the backing driver when the database is flushed or
+// closed, when the row has been dirty for longer than
+// MaxAge, if MaxAge is set, or when the row is evicted
+// because the cache holds more than MaxKeys rows or an
+// estimated MaxBytes of windows. Clean rows are evicted
+// before dirty rows.
+//
+// Because writes are delayed, checkpoints of processed
+// data are only durable after a call to Flush on the
+// database returns.
+type CacheCfg struct {
+	Backing  driver.Cfg
+	MaxKeys  int
+	MaxBytes int
+	MaxAge   time.Duration
+}
+
+// Driver name of the backing driver.
+func (c CacheCfg) Driver() string {
+	return c.Backing.Driver()
+}
+
+func newCache(conn driver.Conn, cfg CacheCfg) *cache {
+	if cfg.MaxKeys <= 0 {
+		cfg.MaxKeys = defaultCacheKeys
+	}
+	c := &cache{
+		cfg:     cfg,
+		conn:    conn,
+		lru:     list.New(),
+		stop:    make(chan struct{}),
+		entries: map[string]*list.Element{},
+	}
+	if cfg.MaxAge > 0 {
+		go c.runFlusher()
+	}
+	return c
+}
+
+// cache of rows in front of a driver connection. A single
+// lock is held for every operation, because the reducer
+// applies mutations sequentially anyway, and it makes
+// the ordering of loads, evictions and write-backs of
+// the same key trivially correct.
+type cache struct {
+	mu      sync.Mutex
+	cfg     CacheCfg
+	conn    driver.Conn
+	lru     *list.List
+	bytes   int
+	closed  bool
+	stop    chan struct{}
+	entries map[string]*list.Element
+}
+
+func (c *cache) Apply(ctx context.Context, key string, mut driver.Mutation) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, err := c.lookup(ctx, key)
+	if err != nil {
+		return err
+	}
+
+	row, err := driver.NewRow(e)
+	if err != nil {
+		return err
+	}
+
+	err = mut(row)
+	if err != nil {
+		return err
+	}
+
+	before := e.bytes
+	err = row.Flush()
+	c.bytes += e.bytes - before
+	if err != nil {
+		return err
+	}
+
+	return c.evict(ctx)
+}
+
+// Drain cached rows from memory, and other rows from the
+// backing driver, so draining writes nothing back.
+func (c *cache) Drain(ctx context.Context, keys []string, filter driver.Filter, sink driver.Sink) error {
+	var uncached []string
+	for _, key := range keys {
+		c.mu.Lock()
+		el, ok := c.entries[key]
+		if !ok {
+			c.mu.Unlock()
+			uncached = append(uncached, key)
+			continue
+		}
+		// Values are replaced, never modified in
+		// place, so a shallow copy is a snapshot.
+		windows := map[window.Span][]interface{}{}
+		for s, vs := range el.Value.(*entry).windows {
+			if filter.Keep(key, s) {
+				windows[s] = vs
+			}
+		}
+		c.mu.Unlock()
+
+		for s, vs := range windows {
+			err := sink(ctx, s, key, vs)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	if len(uncached) == 0 {
+		return nil
+	}
+	return c.conn.Drain(ctx, uncached, filter, sink)
+}
+
+func (c *cache) Scan(ctx context.Context, sink driver.Sink) error {
+	err := c.Flush(ctx)
+	if err != nil {
+		return err
+	}
+	return c.conn.Scan(ctx, sink)
+}
+
+func (c *cache) Compact(ctx context.Context, before time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Expired windows are dropped from cached rows,
+	// including unwritten changes to them, since the
+	// backing driver compacts its own copies.
+	for _, el := range c.entries {
+		e := el.Value.(*entry)
+		for s := range e.windows {
+			if s.Kind() == window.Time && s.End().Before(before) {
+				c.bytes -= e.forget(s)
+			}
+		}
+	}
+
+	return c.conn.Compact(ctx, before)
+}
+
+// Flush every dirty row to the backing driver.
+func (c *cache) Flush(ctx context.Context) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, el := range c.entries {
+		err := c.writeBack(ctx, el.Value.(*entry))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *cache) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil
+	}
+	c.closed = true
+	close(c.stop)
+	c.mu.Unlock()
+
+	err := c.Flush(context.Background())
+	if err != nil {
+		c.conn.Close()
+		return err
+	}
+	return c.conn.Close()
+}
+
+// lookup the entry of the key, loading it from the
+// backing driver if it is not cached, and marking
+// it as most recently used.
+func (c *cache) lookup(ctx context.Context, key string) (*entry, error) {
+	el, ok := c.entries[key]
+	if ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*entry), nil
+	}
+
+	e := newEntry(key)
+	err := c.conn.Drain(ctx, []string{key}, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		e.windows[s] = vs
+		e.bytes += sizeOf(vs)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.bytes += e.bytes
+	c.entries[key] = c.lru.PushFront(e)
+	return e, nil
+}
+
+// evict least recently used rows until the cache is
+// within its bounds. Clean rows are evicted first, dirty
+// rows are written back only when no clean row is left,
+// the most recently used row is never evicted.
+func (c *cache) evict(ctx context.Context) error {
+	full := func() bool {
+		return c.lru.Len() > c.cfg.MaxKeys || (c.cfg.MaxBytes > 0 && c.bytes > c.cfg.MaxBytes)
+	}
+	for el := c.lru.Back(); full() && el != c.lru.Front(); {
+		prev := el.Prev()
+		if e := el.Value.(*entry); !e.dirty() {
+			c.remove(el)
+		}
+		el = prev
+	}
+	for full() && c.lru.Len() > 1 {
+		el := c.lru.Back()
+		err := c.writeBack(ctx, el.Value.(*entry))
+		if err != nil {
+			return err
+		}
+		c.remove(el)
+	}
+	return nil
+}
+
+// remove the row from the cache.
+func (c *cache) remove(el *list.Element) {
+	e := el.Value.(*entry)
+	c.bytes -= e.bytes
+	c.lru.Remove(el)
+	delete(c.entries, e.key)
+}
+
+// writeBack the changes of a dirty entry to the backing driver.
+func (c *cache) writeBack(ctx context.Context, e *entry) error {
+	if !e.dirty() {
+		return nil
+	}
+	err := c.conn.Apply(ctx, e.key, func(state window.State) error {
+		for s := range e.dels {
+			state.Del(s)
+		}
+		for s := range e.puts {
+			state.Set(s, e.windows[s])
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	e.clean()
+	return nil
+}
+
+func (c *cache) runFlusher() {
+	ticker := time.NewTicker(c.cfg.MaxAge / 2)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stop:
+			return
+		case now := <-ticker.C:
+			c.mu.Lock()
+			for _, el := range c.entries {
+				e := el.Value.(*entry)
+				if !e.dirty() || now.Sub(e.since) < c.cfg.MaxAge {
+					continue
+				}
+				// Failures are retried at the next tick,
+				// or surface on the next drain or flush.
+				c.writeBack(context.Background(), e)
+			}
+			c.mu.Unlock()
+		}
+	}
+}
+
+func newEntry(key string) *entry {
+	return &entry{
+		key:     key,
+		puts:    map[window.Span]bool{},
+		dels:    map[window.Span]bool{},
+		windows: map[window.Span][]interface{}{},
+	}
+}
+
+// entry is a cached row, it implements driver.ReadWriter
+// so that mutations are applied to it like any other row.
+type entry struct {
+	key     string
+	bytes   int
+	puts    map[window.Span]bool
+	dels    map[window.Span]bool
+	since   time.Time
+	windows map[window.Span][]interface{}
+}
+
+func (e *entry) DelSpan(s window.Span) error {
+	e.touch()
+	e.forget(s)
+	e.dels[s] = true
+	return nil
+}
+
+func (e *entry) PutSpan(s window.Span, vs []interface{}) error {
+	e.touch()
+	e.forget(s)
+	e.windows[s] = vs
+	e.bytes += sizeOf(vs)
+	e.puts[s] = true
+	delete(e.dels, s)
+	return nil
+}
+
+// forget the window, without deleting it from the backing
+// driver, returning the bytes it was estimated to hold.
+func (e *entry) forget(s window.Span) int {
+	vs, ok := e.windows[s]
+	if !ok {
+		return 0
+	}
+	n := sizeOf(vs)
+	e.bytes -= n
+	delete(e.windows, s)
+	delete(e.puts, s)
+	return n
+}
+
+func (e *entry) GetSpan(s window.Span) ([]interface{}, error) {
+	return e.windows[s], nil
+}
+
+func (e *entry) Overlapping(s window.Span) (map[window.Span][]interface{}, error) {
+	snap := map[window.Span][]interface{}{}
+	for k, vs := range e.windows {
+		if k.Overlap(s) {
+			snap[k] = vs
+		}
+	}
+	return snap, nil
+}
+
+// Windows of the entry, which are not copied, rows only
+// read the map and write through PutSpan and DelSpan.
+func (e *entry) Windows() (map[window.Span][]interface{}, error) {
+	return e.windows, nil
+}
+
+func (e *entry) touch() {
+	if !e.dirty() {
+		e.since = time.Now()
+	}
+}
+
+func (e *entry) dirty() bool {
+	return len(e.puts) > 0 || len(e.dels) > 0
+}
+
+func (e *entry) clean() {
+	e.puts = map[window.Span]bool{}
+	e.dels = map[window.Span]bool{}
+}
+
+// sizeOf the window holding vs, estimated from the encoded
+// size of protobuf messages.
+func sizeOf(vs []interface{}) int {
+	n := spanBytes
+	for _, v := range vs {
+		if m, ok := v.(proto.Message); ok {
+			n += proto.Size(m)
+		} else {
+			n += valueBytes
+		}
+	}
+	return n
+}
@@ -0,0 +1,202 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/window"
+)
+
+// countingConn is a backing connection which counts the
+// mutations applied to it.
+type countingConn struct {
+	applies int
+	closes  int
+	rows    map[string]*countingRW
+}
+
+func newCountingConn() *countingConn {
+	return &countingConn{rows: map[string]*countingRW{}}
+}
+
+func (c *countingConn) Apply(ctx context.Context, key string, mut driver.Mutation) error {
+	c.applies++
+	rw, ok := c.rows[key]
+	if !ok {
+		rw = &countingRW{data: map[window.Span][]interface{}{}}
+		c.rows[key] = rw
+	}
+	row, err := driver.NewRow(rw)
+	if err != nil {
+		return err
+	}
+	err = mut(row)
+	if err != nil {
+		return err
+	}
+	return row.Flush()
+}
+
+func (c *countingConn) Drain(ctx context.Context, keys []string, filter driver.Filter, sink driver.Sink) error {
+	for _, key := range keys {
+		rw, ok := c.rows[key]
+		if !ok {
+			continue
+		}
+		for s, vs := range rw.data {
+			if !filter.Keep(key, s) {
+				continue
+			}
+			err := sink(ctx, s, key, vs)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (c *countingConn) Scan(ctx context.Context, sink driver.Sink) error {
+	keys := []string{}
+	for key := range c.rows {
+		keys = append(keys, key)
+	}
+	return c.Drain(ctx, keys, nil, sink)
+}
+
+func (c *countingConn) Compact(ctx context.Context, before time.Time) error {
+	return nil
+}
+
+func (c *countingConn) Close() error {
+	c.closes++
+	return nil
+}
+
+type countingRW struct {
+	data map[window.Span][]interface{}
+}
+
+func (rw *countingRW) DelSpan(s window.Span) error {
+	delete(rw.data, s)
+	return nil
+}
+
+func (rw *countingRW) PutSpan(s window.Span, vs []interface{}) error {
+	rw.data[s] = vs
+	return nil
+}
+
+func (rw *countingRW) GetSpan(s window.Span) ([]interface{}, error) {
+	return rw.data[s], nil
+}
+
+func (rw *countingRW) Overlapping(s window.Span) (map[window.Span][]interface{}, error) {
+	snap := map[window.Span][]interface{}{}
+	for k, v := range rw.data {
+		if k.Overlap(s) {
+			snap[k] = v
+		}
+	}
+	return snap, nil
+}
+
+func (rw *countingRW) Windows() (map[window.Span][]interface{}, error) {
+	return rw.data, nil
+}
+
+var cacheSpan = window.NewSpan(time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC), time.Date(2017, 01, 01, 14, 0, 0, 0, time.UTC))
+
+func cacheAdd(t *testing.T, c *cache, key string, v int) {
+	err := c.Apply(context.Background(), key, func(state window.State) error {
+		state.Set(cacheSpan, append(state.Get(cacheSpan), v))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCacheDelaysWrites(t *testing.T) {
+	ctx := context.Background()
+	conn := newCountingConn()
+	c := newCache(conn, CacheCfg{})
+
+	cacheAdd(t, c, "a", 1)
+	cacheAdd(t, c, "a", 2)
+
+	// Draining reads the cached row, without
+	// writing it back.
+	n := 0
+	err := c.Drain(ctx, []string{"a"}, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		n = len(vs)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 || conn.applies != 0 {
+		t.Fatalf("expected two cached values and no writes, found: %v values, %v writes", n, conn.applies)
+	}
+
+	err = c.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.applies != 1 || len(conn.rows["a"].data[cacheSpan]) != 2 {
+		t.Fatalf("expected one write of both values, found: %v writes", conn.applies)
+	}
+
+	// Closing twice closes the backing connection once.
+	err = c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = c.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if conn.closes != 1 {
+		t.Fatalf("expected one close, found: %v", conn.closes)
+	}
+}
+
+func TestCacheMaxBytes(t *testing.T) {
+	ctx := context.Background()
+	conn := newCountingConn()
+
+	// Room for about two rows of one value.
+	c := newCache(conn, CacheCfg{MaxBytes: 2 * (spanBytes + valueBytes)})
+
+	cacheAdd(t, c, "a", 1)
+	cacheAdd(t, c, "b", 1)
+	err := c.Flush(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	applies := conn.applies
+
+	// The clean rows are evicted before the dirty
+	// row c, which is kept without being written.
+	cacheAdd(t, c, "c", 1)
+	cacheAdd(t, c, "c", 2)
+	if conn.applies != applies {
+		t.Fatalf("expected no writes, found: %v", conn.applies-applies)
+	}
+	if c.bytes > c.cfg.MaxBytes {
+		t.Fatalf("expected at most %v bytes, found: %v", c.cfg.MaxBytes, c.bytes)
+	}
+	if _, ok := c.entries["c"]; !ok || len(c.entries) != 1 {
+		t.Fatalf("expected only row c to be cached, found: %v rows", len(c.entries))
+	}
+
+	// Dirty rows are written back once they are all
+	// that is left to evict.
+	cacheAdd(t, c, "d", 1)
+	cacheAdd(t, c, "d", 2)
+	if conn.applies != applies+1 || len(conn.rows["c"].data[cacheSpan]) != 2 {
+		t.Fatalf("expected row c to be written back, found: %v writes", conn.applies-applies)
+	}
+}
@@ -0,0 +1,57 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/storage"
+	"github.com/lytics/flo/storage/driver/memdriver"
+	"github.com/lytics/flo/window"
+)
+
+func TestCacheWriteBack(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	span := window.NewSpan(t0, t0.Add(1*time.Hour))
+
+	// Cache with room for only one key, so that
+	// the second key evicts the first.
+	db, err := storage.Open("cache", storage.CacheCfg{
+		Backing: memdriver.Cfg{},
+		MaxKeys: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	add := func(key string, v int) {
+		err := db.Apply(ctx, key, func(state window.State) error {
+			state.Set(span, append(state.Get(span), v))
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	add("a", 1)
+	add("a", 2)
+	add("b", 3)
+	add("a", 4)
+
+	expected := map[string]int{"a": 3, "b": 1}
+	err = db.Drain(ctx, []string{"a", "b"}, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		if len(vs) != expected[key] {
+			t.Fatalf("expected key: %v, to have %v values, got: %v", key, expected[key], vs)
+		}
+		delete(expected, key)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) != 0 {
+		t.Fatalf("expected keys were not drained: %v", expected)
+	}
+}
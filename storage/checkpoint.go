@@ -0,0 +1,55 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lytics/flo/internal/codec"
+	"github.com/lytics/flo/window"
+)
+
+// checkpointKey prefixes the keys under which checkpoints
+// are stored, alongside the windows of keys.
+const checkpointKey = "\x00checkpoint."
+
+// checkpointSpan of every checkpoint, it is a closed
+// ordinal span so checkpoints are never compacted.
+var checkpointSpan = window.NewOrdinalSpan(0, 0).Close()
+
+func init() {
+	codec.Register(Checkpoint{})
+}
+
+// PutCheckpoint of the given name, such as the state
+// of a trigger, replacing any previous checkpoint.
+func (db *DB) PutCheckpoint(ctx context.Context, name string, data []byte) error {
+	return db.conn.Apply(ctx, checkpointKey+name, func(state window.State) error {
+		state.Set(checkpointSpan, []interface{}{&Checkpoint{Data: data}})
+		return nil
+	})
+}
+
+// GetCheckpoint of the given name, nil is returned when
+// no checkpoint exists. It is only read, so it never
+// takes a write transaction.
+func (db *DB) GetCheckpoint(ctx context.Context, name string) ([]byte, error) {
+	var data []byte
+	filter := func(key string, s window.Span) bool {
+		return s == checkpointSpan
+	}
+	err := db.conn.Drain(ctx, []string{checkpointKey + name}, filter, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		if len(vs) == 0 {
+			return nil
+		}
+		c, ok := vs[0].(*Checkpoint)
+		if !ok {
+			return fmt.Errorf("storage: invalid checkpoint: %v", name)
+		}
+		data = c.Data
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
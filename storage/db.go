@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sync"
+	"time"
 
 	"github.com/lytics/flo/storage/driver"
 )
@@ -25,8 +26,14 @@ func Register(name string, d driver.Driver) {
 	drivers[name] = d
 }
 
-// Open a new database connection.
+// Open a new database connection. When cfg is a CacheCfg
+// the connection of the backing driver is wrapped with
+// a write-back cache.
 func Open(name string, cfg driver.Cfg) (*DB, error) {
+	cacheCfg, cached := cfg.(CacheCfg)
+	if cached {
+		cfg = cacheCfg.Backing
+	}
 	drvr, ok := drivers[cfg.Driver()]
 	if !ok {
 		return nil, fmt.Errorf("storage: unknown driver: %v", cfg.Driver())
@@ -35,6 +42,9 @@ func Open(name string, cfg driver.Cfg) (*DB, error) {
 	if err != nil {
 		return nil, fmt.Errorf("storage: failed to open: %v", err)
 	}
+	if cached {
+		conn = newCache(conn, cacheCfg)
+	}
 	return &DB{
 		conn: conn,
 	}, nil
@@ -45,12 +55,42 @@ type DB struct {
 	conn driver.Conn
 }
 
+// flusher is implemented by connections which
+// delay writes, such as the write-back cache.
+type flusher interface {
+	Flush(ctx context.Context) error
+}
+
 // Apply the mutation.
 func (db *DB) Apply(ctx context.Context, key string, mut driver.Mutation) error {
 	return db.conn.Apply(ctx, key, mut)
 }
 
-// Drain the keys into the sink.
-func (db *DB) Drain(ctx context.Context, keys []string, sink driver.Sink) error {
-	return db.conn.Drain(ctx, keys, sink)
+// Drain the windows of the keys kept by the filter into
+// the sink. A nil filter drains every window.
+func (db *DB) Drain(ctx context.Context, keys []string, filter driver.Filter, sink driver.Sink) error {
+	return db.conn.Drain(ctx, keys, filter, sink)
+}
+
+// Compact the database by deleting every time window,
+// of every key, whose end is before the given time.
+// Ordinal windows are never compacted.
+func (db *DB) Compact(ctx context.Context, before time.Time) error {
+	return db.conn.Compact(ctx, before)
+}
+
+// Flush delayed writes, if any, to durable storage. Source
+// checkpoints must not be committed before their data has
+// been flushed.
+func (db *DB) Flush(ctx context.Context) error {
+	f, ok := db.conn.(flusher)
+	if !ok {
+		return nil
+	}
+	return f.Flush(ctx)
+}
+
+// Close the database connection.
+func (db *DB) Close() error {
+	return db.conn.Close()
 }
@@ -0,0 +1,119 @@
+package storage_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/storage"
+	"github.com/lytics/flo/storage/driver/memdriver"
+	"github.com/lytics/flo/window"
+)
+
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+
+	old := window.NewSpan(t0, t0.Add(1*time.Hour))
+	recent := window.NewSpan(t0.Add(1*time.Hour), t0.Add(2*time.Hour))
+
+	db, err := storage.Open("compact", memdriver.Cfg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Apply(ctx, "key", func(state window.State) error {
+		state.Set(old, []interface{}{1})
+		state.Set(recent, []interface{}{2})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The old window ends before the compaction
+	// time, the recent window ends after it.
+	err = db.Compact(ctx, t0.Add(90*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	found := map[window.Span]bool{}
+	err = db.Drain(ctx, []string{"key"}, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		found[s] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found[old] {
+		t.Fatal("expected old window to be compacted")
+	}
+	if !found[recent] {
+		t.Fatal("expected recent window to be kept")
+	}
+}
+
+func TestDrainFilter(t *testing.T) {
+	ctx := context.Background()
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+
+	first := window.NewSpan(t0, t0.Add(1*time.Hour))
+	second := window.NewSpan(t0.Add(1*time.Hour), t0.Add(2*time.Hour))
+
+	db, err := storage.Open("drain-filter", memdriver.Cfg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Apply(ctx, "key", func(state window.State) error {
+		state.Set(first, []interface{}{1})
+		state.Set(second, []interface{}{2})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	only := func(key string, s window.Span) bool {
+		return s == second
+	}
+	found := map[window.Span]bool{}
+	err = db.Drain(ctx, []string{"key"}, only, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		found[s] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found[first] || !found[second] {
+		t.Fatalf("expected only the second window, found: %v", found)
+	}
+}
+
+func TestCheckpoint(t *testing.T) {
+	ctx := context.Background()
+
+	db, err := storage.Open("checkpoint", memdriver.Cfg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := db.GetCheckpoint(ctx, "trigger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data != nil {
+		t.Fatalf("expected no checkpoint, found: %s", data)
+	}
+
+	err = db.PutCheckpoint(ctx, "trigger", []byte("state"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err = db.GetCheckpoint(ctx, "trigger")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "state" {
+		t.Fatalf("expected checkpoint: state, found: %s", data)
+	}
+}
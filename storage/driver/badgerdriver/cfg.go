@@ -0,0 +1,14 @@
+package badgerdriver
+
+import "github.com/dgraph-io/badger"
+
+// Cfg for Badger database.
+type Cfg struct {
+	BaseDir string
+	Options *badger.Options
+}
+
+// Driver name.
+func (c Cfg) Driver() string {
+	return DriverName
+}
@@ -4,8 +4,8 @@ import (
 	"fmt"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/lytics/flo/internal/codec"
 	"github.com/lytics/flo/window"
-	"github.com/lytics/grid/codec"
 )
 
 func encodeKey(s window.Span, rw *rw) ([]byte, error) {
@@ -28,6 +28,16 @@ func encodeKey(s window.Span, rw *rw) ([]byte, error) {
 	return fk, nil
 }
 
+// rowPrefix of every key of the row, including the
+// separator, so that the row of key "a" does not
+// include the keys of the row "ab".
+func rowPrefix(rw *rw) []byte {
+	p := make([]byte, len(rw.prefix)+1)
+	copy(p, rw.prefix)
+	p[len(rw.prefix)] = '@'
+	return p
+}
+
 func decodeKey(kb []byte, rw *rw) (window.Span, error) {
 	pl := len(rw.prefix)
 
@@ -39,19 +49,64 @@ func decodeKey(kb []byte, rw *rw) (window.Span, error) {
 	return window.NewSpanFromKey(kb[pl+1:])
 }
 
+// splitKey into its row key and span, without knowing
+// the row prefix in advance, as is the case when
+// iterating over the whole database.
+func splitKey(kb []byte) (string, window.Span, error) {
+	// Keys written before the current span key
+	// version are still read, until upgraded.
+	for _, v := range window.SpanKeyVersions {
+		pl := len(kb) - window.SpanKeySize(v) - 1
+
+		// Expected format: <prefix>@<span>
+		if pl < 0 || kb[pl] != '@' || window.SpanKeyVersion(kb[pl+1:]) != v {
+			continue
+		}
+
+		s, err := window.NewSpanFromKey(kb[pl+1:])
+		if err != nil {
+			return "", window.Span{}, err
+		}
+		return string(kb[:pl]), s, nil
+	}
+	return "", window.Span{}, fmt.Errorf("invalid key: %x", kb)
+}
+
+// upgradeKey written with a legacy span key version
+// to the current version. Returns nil if the key is
+// already current.
+func upgradeKey(kb []byte) ([]byte, error) {
+	key, s, err := splitKey(kb)
+	if err != nil {
+		return nil, err
+	}
+	if window.SpanKeyVersion(kb[len(key)+1:]) == window.SpanKeyCurrent {
+		return nil, nil
+	}
+	sk, err := s.Key()
+	if err != nil {
+		return nil, err
+	}
+	fk := make([]byte, len(key)+1+len(sk))
+	copy(fk, key)
+	fk[len(key)] = '@'
+	copy(fk[len(key)+1:], sk)
+	return fk, nil
+}
+
 func encodeVal(vs []interface{}) ([]byte, error) {
 	vec := &Vector{}
 	dataType := ""
 	for _, v := range vs {
 		datumType, datum, err := codec.Marshal(v)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("badgerdriver: failed to encode: %v", err)
 		}
 		if dataType == "" {
 			dataType = datumType
 		}
 		if dataType != datumType {
-			return nil, fmt.Errorf("invalid encoded data type: %v, expected: %v", datumType, dataType)
+			return nil, fmt.Errorf("badgerdriver: invalid encoded data type: %v, expected: %v", datumType, dataType)
 		}
 		vec.Data = append(vec.Data, datum)
 	}
@@ -71,7 +126,7 @@ func decodeVal(vb []byte) ([]interface{}, error) {
 	for _, e := range vec.Data {
 		v, err := codec.Unmarshal(e, vec.DataType)
 		if err != nil {
-			return nil, err
+			return nil, fmt.Errorf("badgerdriver: failed to decode: %v", err)
 		}
 		res = append(res, v)
 	}
@@ -2,26 +2,63 @@ package badgerdriver
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"path"
+	"time"
 
 	"github.com/dgraph-io/badger"
 	"github.com/lytics/flo/storage"
 	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/window"
+)
+
+const (
+	// DriverName used for driver registration.
+	DriverName = "badger"
 )
 
 func init() {
-	storage.Register("badger", &drvr{})
+	storage.Register(DriverName, &drvr{})
 }
 
 type drvr struct{}
 
-func (d *drvr) Open(name string) (driver.Conn, error) {
-	db, err := badger.Open(badger.Options{Dir: name, ValueDir: name})
+func (d *drvr) Open(name string, cfg driver.Cfg) (driver.Conn, error) {
+	badgerCfg, ok := cfg.(Cfg)
+	if !ok {
+		return nil, fmt.Errorf("badgerdriver: unknown configuration type: %T", cfg)
+	}
+
+	// Location of database.
+	loc := path.Join(badgerCfg.BaseDir, name)
+	err := os.MkdirAll(loc, 0700)
+	if err != nil {
+		return nil, err
+	}
+
+	// Options for database.
+	opt := badger.DefaultOptions
+	if badgerCfg.Options != nil {
+		opt = *badgerCfg.Options
+	}
+	opt.Dir = loc
+	opt.ValueDir = loc
+
+	db, err := badger.Open(opt)
 	if err != nil {
 		return nil, err
 	}
-	return &Conn{
+
+	c := &Conn{
 		db: db,
-	}, nil
+	}
+	err = c.upgrade()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return c, nil
 }
 
 type Conn struct {
@@ -46,6 +83,135 @@ func (c *Conn) Apply(ctx context.Context, key string, mut driver.Mutation) error
 	})
 }
 
-func (c *Conn) Drain(ctx context.Context, keys []string, sink driver.Sink) error {
-	return nil
+func (c *Conn) Drain(ctx context.Context, keys []string, filter driver.Filter, sink driver.Sink) error {
+	return c.db.View(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			rw := newRW(key, txn)
+
+			row, err := driver.NewRow(rw)
+			if err != nil {
+				return err
+			}
+
+			for s, vs := range row.Windows() {
+				if !filter.Keep(key, s) {
+					continue
+				}
+				err := sink(ctx, s, key, vs)
+				if err != nil {
+					return err
+				}
+			}
+			if row.Err() != nil {
+				return row.Err()
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *Conn) Scan(ctx context.Context, sink driver.Sink) error {
+	return c.db.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			key, s, err := splitKey(item.Key())
+			if err != nil {
+				return err
+			}
+			vb, err := item.Value()
+			if err != nil {
+				return err
+			}
+			vs, err := decodeVal(vb)
+			if err != nil {
+				return err
+			}
+			err = sink(ctx, s, key, vs)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *Conn) Compact(ctx context.Context, before time.Time) error {
+	return c.db.Update(func(txn *badger.Txn) error {
+		var expired [][]byte
+
+		it := txn.NewIterator(badger.IteratorOptions{})
+		for it.Rewind(); it.Valid(); it.Next() {
+			kb := it.Item().Key()
+			_, s, err := splitKey(kb)
+			if err != nil {
+				it.Close()
+				return err
+			}
+			if s.Kind() == window.Time && s.End().Before(before) {
+				expired = append(expired, append([]byte(nil), kb...))
+			}
+		}
+		it.Close()
+
+		for _, kb := range expired {
+			err := txn.Delete(kb)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *Conn) Close() error {
+	return c.db.Close()
+}
+
+// upgrade keys written with a legacy span key version,
+// so that the spans of a row sort in order again.
+func (c *Conn) upgrade() error {
+	return c.db.Update(func(txn *badger.Txn) error {
+		var olds, news, vals [][]byte
+
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		for it.Rewind(); it.Valid(); it.Next() {
+			item := it.Item()
+			nk, err := upgradeKey(item.Key())
+			if err != nil {
+				it.Close()
+				return err
+			}
+			if nk == nil {
+				continue
+			}
+			vb, err := item.Value()
+			if err != nil {
+				it.Close()
+				return err
+			}
+			olds = append(olds, append([]byte(nil), item.Key()...))
+			news = append(news, nk)
+			vals = append(vals, append([]byte(nil), vb...))
+		}
+		it.Close()
+
+		for i := range olds {
+			err := txn.Set(news[i], vals[i], 0)
+			if err != nil {
+				return err
+			}
+			err = txn.Delete(olds[i])
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
 }
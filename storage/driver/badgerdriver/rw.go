@@ -38,25 +38,87 @@ func (rw *rw) PutSpan(s window.Span, vs []interface{}) error {
 	return rw.txn.Set(k, v, 0)
 }
 
+func (rw *rw) GetSpan(s window.Span) ([]interface{}, error) {
+	k, err := encodeKey(s, rw)
+	if err != nil {
+		return nil, err
+	}
+	item, err := rw.txn.Get(k)
+	if err == badger.ErrKeyNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	vb, err := item.Value()
+	if err != nil {
+		return nil, err
+	}
+	return decodeVal(vb)
+}
+
+func (rw *rw) Overlapping(s window.Span) (map[window.Span][]interface{}, error) {
+	snap := map[window.Span][]interface{}{}
+	err := rw.scan(func(k window.Span, item *badger.Item) (bool, error) {
+		// Time spans sort first, by start, so
+		// no later span can overlap s.
+		if s.Kind() == window.Time && !k.Start().Before(s.End()) {
+			return false, nil
+		}
+		if !k.Overlap(s) {
+			return true, nil
+		}
+		v, err := decodeItem(item)
+		if err != nil {
+			return false, err
+		}
+		snap[k] = v
+		return true, nil
+	})
+	return snap, err
+}
+
 func (rw *rw) Windows() (map[window.Span][]interface{}, error) {
-	it := rw.txn.NewIterator(badger.DefaultIteratorOptions)
 	snap := map[window.Span][]interface{}{}
-	for it.Seek(rw.prefix); it.ValidForPrefix(rw.prefix); it.Next() {
-		item := it.Item()
-		kb := item.Key()
-		vb, err := item.Value()
+	err := rw.scan(func(k window.Span, item *badger.Item) (bool, error) {
+		v, err := decodeItem(item)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
-		k, err := decodeKey(kb, rw)
+		snap[k] = v
+		return true, nil
+	})
+	return snap, err
+}
+
+// scan the spans of the row in order of their start,
+// until f returns false or an error.
+func (rw *rw) scan(f func(k window.Span, item *badger.Item) (bool, error)) error {
+	it := rw.txn.NewIterator(badger.DefaultIteratorOptions)
+	defer it.Close()
+
+	prefix := rowPrefix(rw)
+	for it.Seek(prefix); it.ValidForPrefix(prefix); it.Next() {
+		item := it.Item()
+		k, err := decodeKey(item.Key(), rw)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		v, err := decodeVal(vb)
+		more, err := f(k, item)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		snap[k] = v
+		if !more {
+			return nil
+		}
+	}
+	return nil
+}
+
+func decodeItem(item *badger.Item) ([]interface{}, error) {
+	vb, err := item.Value()
+	if err != nil {
+		return nil, err
 	}
-	return snap, nil
+	return decodeVal(vb)
 }
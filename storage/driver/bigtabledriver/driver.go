@@ -2,10 +2,12 @@ package bigtabledriver
 
 import (
 	"context"
+	"time"
 
 	"cloud.google.com/go/bigtable"
 	"github.com/lytics/flo/storage"
 	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/window"
 )
 
 func init() {
@@ -20,12 +22,14 @@ func (d *drvr) Open(name string) (driver.Conn, error) {
 		return nil, err
 	}
 	return &Conn{
-		table: client.Open(""),
+		client: client,
+		table:  client.Open(""),
 	}, nil
 }
 
 type Conn struct {
-	table *bigtable.Table
+	client *bigtable.Client
+	table  *bigtable.Table
 }
 
 func (c *Conn) Apply(ctx context.Context, key string, mut driver.Mutation) error {
@@ -49,6 +53,63 @@ func (c *Conn) Apply(ctx context.Context, key string, mut driver.Mutation) error
 	return rw.flush()
 }
 
-func (c *Conn) Drain(ctx context.Context, keys []string, sink driver.Sink) error {
+func (c *Conn) Drain(ctx context.Context, keys []string, filter driver.Filter, sink driver.Sink) error {
 	return nil
 }
+
+func (c *Conn) Scan(ctx context.Context, sink driver.Sink) error {
+	var err error
+	rerr := c.table.ReadRows(ctx, bigtable.InfiniteRange(""), func(row bigtable.Row) bool {
+		var snap map[window.Span][]interface{}
+		snap, err = decodeRow(row)
+		if err != nil {
+			return false
+		}
+		for s, vs := range snap {
+			err = sink(ctx, s, row.Key(), vs)
+			if err != nil {
+				return false
+			}
+		}
+		return true
+	}, bigtable.RowFilter(bigtable.FamilyFilter(windowFamily)))
+	if rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+func (c *Conn) Compact(ctx context.Context, before time.Time) error {
+	var err error
+	rerr := c.table.ReadRows(ctx, bigtable.InfiniteRange(""), func(row bigtable.Row) bool {
+		mut := bigtable.NewMutation()
+		expired := 0
+		for _, item := range row[windowFamily] {
+			var s window.Span
+			s, err = decodeKey(item.Column)
+			if err != nil {
+				return false
+			}
+			if s.Kind() == window.Time && s.End().Before(before) {
+				mut.DeleteCellsInColumn(windowFamily, item.Column)
+				expired++
+			}
+		}
+		if expired == 0 {
+			return true
+		}
+		err = c.table.Apply(ctx, row.Key(), mut)
+		return err == nil
+	}, bigtable.RowFilter(bigtable.ChainFilters(
+		bigtable.FamilyFilter(windowFamily),
+		bigtable.StripValueFilter(),
+	)))
+	if rerr != nil {
+		return rerr
+	}
+	return err
+}
+
+func (c *Conn) Close() error {
+	return c.client.Close()
+}
@@ -41,11 +41,65 @@ func (rw *rw) PutSpan(s window.Span, vs []interface{}) error {
 	return nil
 }
 
+func (rw *rw) GetSpan(s window.Span) ([]interface{}, error) {
+	k, err := encodeKey(s)
+	if err != nil {
+		return nil, err
+	}
+	row, err := rw.tbl.ReadRow(nil, rw.prefix, bigtable.RowFilter(bigtable.ColumnRangeFilter(windowFamily, k, k+"\x00")))
+	if err != nil {
+		return nil, err
+	}
+	snap, err := decodeRow(row)
+	if err != nil {
+		return nil, err
+	}
+	return snap[s], nil
+}
+
+func (rw *rw) Overlapping(s window.Span) (map[window.Span][]interface{}, error) {
+	// Time columns sort first, by span start, so
+	// only columns before the first possible span
+	// starting at the end of s are read. Ordinal
+	// spans sort after every time span.
+	end := ""
+	if s.Kind() == window.Time {
+		k, err := encodeKey(window.NewSpan(s.End(), s.End()))
+		if err != nil {
+			return nil, err
+		}
+		end = k
+	}
+	row, err := rw.tbl.ReadRow(nil, rw.prefix, bigtable.RowFilter(bigtable.ColumnRangeFilter(windowFamily, "", end)))
+	if err != nil {
+		return nil, err
+	}
+	snap, err := decodeRow(row)
+	if err != nil {
+		return nil, err
+	}
+	for k := range snap {
+		if !k.Overlap(s) {
+			delete(snap, k)
+		}
+	}
+	return snap, nil
+}
+
 func (rw *rw) Windows() (map[window.Span][]interface{}, error) {
 	row, err := rw.tbl.ReadRow(nil, rw.prefix)
 	if err != nil {
 		return nil, err
 	}
+	return decodeRow(row)
+}
+
+func (rw *rw) flush() error {
+	return rw.tbl.Apply(nil, string(rw.prefix), rw.mut)
+}
+
+// decodeRow into the latest version of each window.
+func decodeRow(row bigtable.Row) (map[window.Span][]interface{}, error) {
 	ts := map[window.Span]bigtable.Timestamp{}
 	snap := map[window.Span][]interface{}{}
 	for _, item := range row[windowFamily] {
@@ -64,7 +118,3 @@ func (rw *rw) Windows() (map[window.Span][]interface{}, error) {
 	}
 	return snap, nil
 }
-
-func (rw *rw) flush() error {
-	return rw.tbl.Apply(nil, string(rw.prefix), rw.mut)
-}
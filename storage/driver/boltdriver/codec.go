@@ -32,6 +32,16 @@ func encodeKey(s window.Span, rw *rw) ([]byte, error) {
 	return fk, nil
 }
 
+// rowPrefix of every key of the row, including the
+// separator, so that the row of key "a" does not
+// include the keys of the row "ab".
+func rowPrefix(rw *rw) []byte {
+	p := make([]byte, len(rw.prefix)+1)
+	copy(p, rw.prefix)
+	p[len(rw.prefix)] = '@'
+	return p
+}
+
 func decodeKey(kb []byte, rw *rw) (window.Span, error) {
 	pl := len(rw.prefix)
 
@@ -43,6 +53,51 @@ func decodeKey(kb []byte, rw *rw) (window.Span, error) {
 	return window.NewSpanFromKey(kb[pl+1:])
 }
 
+// splitKey into its row key and span, without knowing
+// the row prefix in advance, as is the case when
+// scanning the whole bucket.
+func splitKey(kb []byte) (string, window.Span, error) {
+	// Keys written before the current span key
+	// version are still read, until upgraded.
+	for _, v := range window.SpanKeyVersions {
+		pl := len(kb) - window.SpanKeySize(v) - 1
+
+		// Expected format: <prefix>@<span>
+		if pl < 0 || kb[pl] != '@' || window.SpanKeyVersion(kb[pl+1:]) != v {
+			continue
+		}
+
+		s, err := window.NewSpanFromKey(kb[pl+1:])
+		if err != nil {
+			return "", window.Span{}, err
+		}
+		return string(kb[:pl]), s, nil
+	}
+	return "", window.Span{}, fmt.Errorf("invalid key: %x", kb)
+}
+
+// upgradeKey written with a legacy span key version
+// to the current version. Returns nil if the key is
+// already current.
+func upgradeKey(kb []byte) ([]byte, error) {
+	key, s, err := splitKey(kb)
+	if err != nil {
+		return nil, err
+	}
+	if window.SpanKeyVersion(kb[len(key)+1:]) == window.SpanKeyCurrent {
+		return nil, nil
+	}
+	sk, err := s.Key()
+	if err != nil {
+		return nil, err
+	}
+	fk := make([]byte, len(key)+1+len(sk))
+	copy(fk, key)
+	fk[len(key)] = '@'
+	copy(fk[len(key)+1:], sk)
+	return fk, nil
+}
+
 func encodeVal(vs []interface{}) ([]byte, error) {
 	vec := &Vector{}
 	dataType := ""
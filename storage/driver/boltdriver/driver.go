@@ -5,10 +5,12 @@ import (
 	"fmt"
 	"os"
 	"path"
+	"time"
 
 	"github.com/boltdb/bolt"
 	"github.com/lytics/flo/storage"
 	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/window"
 )
 
 const (
@@ -61,6 +63,12 @@ func (d *drvr) Open(name string, cfg driver.Cfg) (driver.Conn, error) {
 		return nil, err
 	}
 
+	err = c.upgrade()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
 	return c, nil
 }
 
@@ -88,7 +96,7 @@ func (c *Conn) Apply(ctx context.Context, key string, mut driver.Mutation) error
 	})
 }
 
-func (c *Conn) Drain(ctx context.Context, keys []string, sink driver.Sink) error {
+func (c *Conn) Drain(ctx context.Context, keys []string, filter driver.Filter, sink driver.Sink) error {
 	return c.db.View(func(tx *bolt.Tx) error {
 		bk := tx.Bucket(c.bucketKey())
 
@@ -101,11 +109,108 @@ func (c *Conn) Drain(ctx context.Context, keys []string, sink driver.Sink) error
 			}
 
 			for s, vs := range row.Windows() {
+				if !filter.Keep(key, s) {
+					continue
+				}
 				err := sink(ctx, s, key, vs)
 				if err != nil {
 					return err
 				}
 			}
+			if row.Err() != nil {
+				return row.Err()
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *Conn) Scan(ctx context.Context, sink driver.Sink) error {
+	return c.db.View(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(c.bucketKey())
+
+		return bk.ForEach(func(kb, vb []byte) error {
+			key, s, err := splitKey(kb)
+			if err != nil {
+				return err
+			}
+			vs, err := decodeVal(vb)
+			if err != nil {
+				return err
+			}
+			return sink(ctx, s, key, vs)
+		})
+	})
+}
+
+func (c *Conn) Compact(ctx context.Context, before time.Time) error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(c.bucketKey())
+
+		// Keys are collected first, since deleting
+		// while iterating invalidates the cursor.
+		var expired [][]byte
+		err := bk.ForEach(func(kb, vb []byte) error {
+			_, s, err := splitKey(kb)
+			if err != nil {
+				return err
+			}
+			if s.Kind() == window.Time && s.End().Before(before) {
+				expired = append(expired, append([]byte(nil), kb...))
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, kb := range expired {
+			err := bk.Delete(kb)
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func (c *Conn) Close() error {
+	return c.db.Close()
+}
+
+// upgrade keys written with a legacy span key version,
+// so that the spans of a row sort in order again.
+func (c *Conn) upgrade() error {
+	return c.db.Update(func(tx *bolt.Tx) error {
+		bk := tx.Bucket(c.bucketKey())
+
+		legacy := map[string][]byte{}
+		err := bk.ForEach(func(kb, vb []byte) error {
+			nk, err := upgradeKey(kb)
+			if err != nil {
+				return err
+			}
+			if nk != nil {
+				legacy[string(kb)] = nk
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		for kb, nk := range legacy {
+			vb := append([]byte(nil), bk.Get([]byte(kb))...)
+			err := bk.Put(nk, vb)
+			if err != nil {
+				return err
+			}
+			err = bk.Delete([]byte(kb))
+			if err != nil {
+				return err
+			}
 		}
 
 		return nil
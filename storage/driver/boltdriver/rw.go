@@ -40,19 +40,69 @@ func (rw *rw) PutSpan(s window.Span, vs []interface{}) error {
 	return rw.b.Put(k, v)
 }
 
-func (rw *rw) Windows() (map[window.Span][]interface{}, error) {
-	c := rw.b.Cursor()
+func (rw *rw) GetSpan(s window.Span) ([]interface{}, error) {
+	k, err := encodeKey(s, rw)
+	if err != nil {
+		return nil, err
+	}
+	vb := rw.b.Get(k)
+	if vb == nil {
+		return nil, nil
+	}
+	return decodeVal(vb)
+}
+
+func (rw *rw) Overlapping(s window.Span) (map[window.Span][]interface{}, error) {
 	snap := map[window.Span][]interface{}{}
-	for kb, vb := c.Seek(rw.prefix); kb != nil && bytes.HasPrefix(kb, rw.prefix); kb, vb = c.Next() {
-		k, err := decodeKey(kb, rw)
+	err := rw.scan(func(k window.Span, vb []byte) (bool, error) {
+		// Time spans sort first, by start, so
+		// no later span can overlap s.
+		if s.Kind() == window.Time && !k.Start().Before(s.End()) {
+			return false, nil
+		}
+		if !k.Overlap(s) {
+			return true, nil
+		}
+		v, err := decodeVal(vb)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
+		snap[k] = v
+		return true, nil
+	})
+	return snap, err
+}
+
+func (rw *rw) Windows() (map[window.Span][]interface{}, error) {
+	snap := map[window.Span][]interface{}{}
+	err := rw.scan(func(k window.Span, vb []byte) (bool, error) {
 		v, err := decodeVal(vb)
 		if err != nil {
-			return nil, err
+			return false, err
 		}
 		snap[k] = v
+		return true, nil
+	})
+	return snap, err
+}
+
+// scan the spans of the row in order of their start,
+// until f returns false or an error.
+func (rw *rw) scan(f func(k window.Span, vb []byte) (bool, error)) error {
+	c := rw.b.Cursor()
+	prefix := rowPrefix(rw)
+	for kb, vb := c.Seek(prefix); kb != nil && bytes.HasPrefix(kb, prefix); kb, vb = c.Next() {
+		k, err := decodeKey(kb, rw)
+		if err != nil {
+			return err
+		}
+		more, err := f(k, vb)
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
 	}
-	return snap, nil
+	return nil
 }
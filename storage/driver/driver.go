@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"time"
 
 	"github.com/lytics/flo/window"
 )
@@ -15,16 +16,35 @@ type Driver interface {
 // Conn is a handle to a datastore connection.
 type Conn interface {
 	Apply(ctx context.Context, key string, mut Mutation) error
-	Drain(ctx context.Context, keys []string, sink Sink) error
+	Drain(ctx context.Context, keys []string, filter Filter, sink Sink) error
+	Scan(ctx context.Context, sink Sink) error
+	Compact(ctx context.Context, before time.Time) error
+	Close() error
 }
 
-// ReadWriter of single row data.
+// ReadWriter of single row data. GetSpan returns nil
+// when the span does not exist. Overlapping returns
+// only the windows overlapping span s, and should
+// read no more than needed to find them.
 type ReadWriter interface {
 	DelSpan(s window.Span) error
 	PutSpan(s window.Span, vs []interface{}) error
+	GetSpan(s window.Span) ([]interface{}, error)
+	Overlapping(s window.Span) (map[window.Span][]interface{}, error)
 	Windows() (map[window.Span][]interface{}, error)
 }
 
+// Filter of the windows to drain, a window of a key
+// is drained when the filter returns true. A nil
+// filter drains every window.
+type Filter func(key string, s window.Span) bool
+
+// Keep the window of the key, a nil filter keeps
+// every window.
+func (f Filter) Keep(key string, s window.Span) bool {
+	return f == nil || f(key, s)
+}
+
 // Sink for data output.
 type Sink func(ctx context.Context, span window.Span, key string, vs []interface{}) error
 
@@ -0,0 +1,128 @@
+package leveldbdriver_test
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/internal/codec"
+	"github.com/lytics/flo/internal/codec/protomessage"
+	"github.com/lytics/flo/merger"
+	"github.com/lytics/flo/storage"
+	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/storage/driver/boltdriver"
+	"github.com/lytics/flo/storage/driver/leveldbdriver"
+	"github.com/lytics/flo/window"
+)
+
+func init() {
+	codec.Register(protomessage.Person{})
+}
+
+// event of a benchmark workload.
+type event struct {
+	key  string
+	time time.Time
+	data interface{}
+}
+
+// wordcount workload, like the wordcount example: many keys,
+// one all-of-time window per key, and values folded into
+// a single value.
+func wordcount() (window.Window, merger.ManyMerger, func(i int) event) {
+	t0 := time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC)
+	r := rand.New(rand.NewSource(1))
+	fold := merger.Fold(func(a, b interface{}) (interface{}, error) {
+		// Fold starts from a nil accumulator.
+		if a == nil {
+			return b, nil
+		}
+		return a, nil
+	})
+	return window.All(), fold, func(i int) event {
+		word := fmt.Sprintf("word-%d", r.Intn(5000))
+		return event{
+			key:  word,
+			time: t0,
+			data: &protomessage.Person{Name: word},
+		}
+	}
+}
+
+// sessions workload, like the sessions example: few keys,
+// session windows per key, and values appended.
+func sessions() (window.Window, merger.ManyMerger, func(i int) event) {
+	t0 := time.Date(2017, 01, 01, 0, 0, 0, 0, time.UTC)
+	r := rand.New(rand.NewSource(1))
+	return window.Session(30 * time.Minute), merger.Cons(), func(i int) event {
+		user := fmt.Sprintf("user-%d", r.Intn(100))
+		return event{
+			key:  user,
+			time: t0.Add(time.Duration(i) * time.Minute),
+			data: &protomessage.Person{Name: user, Email: fmt.Sprintf("/page/%d", r.Intn(50))},
+		}
+	}
+}
+
+func bench(b *testing.B, cfg func(dir string) driver.Cfg, workload func() (window.Window, merger.ManyMerger, func(i int) event)) {
+	dir, err := ioutil.TempDir("", "flo-bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	db, err := storage.Open("bench", cfg(dir))
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer db.Close()
+
+	ctx := context.Background()
+	w, f, next := workload()
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		e := next(i)
+		for _, s := range w.Apply(e.time, e.data) {
+			err := db.Apply(ctx, e.key, func(state window.State) error {
+				return w.Merge(s, e.data, state, f)
+			})
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+// boltCfg of the default options. Bolt applies mutations
+// in batches, which wait up to its batch delay for more
+// writers, so serial benchmarks mostly measure the delay.
+func boltCfg(dir string) driver.Cfg {
+	return boltdriver.Cfg{BaseDir: dir, FileMode: 0600}
+}
+
+// levelCfg syncs every write, as bolt does, so both
+// drivers are compared with the same durability.
+func levelCfg(dir string) driver.Cfg {
+	return leveldbdriver.Cfg{BaseDir: dir, Sync: leveldbdriver.SyncWrites}
+}
+
+func BenchmarkWordcountBolt(b *testing.B) {
+	bench(b, boltCfg, wordcount)
+}
+
+func BenchmarkWordcountLevelDB(b *testing.B) {
+	bench(b, levelCfg, wordcount)
+}
+
+func BenchmarkSessionsBolt(b *testing.B) {
+	bench(b, boltCfg, sessions)
+}
+
+func BenchmarkSessionsLevelDB(b *testing.B) {
+	bench(b, levelCfg, sessions)
+}
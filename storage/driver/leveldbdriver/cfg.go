@@ -0,0 +1,34 @@
+package leveldbdriver
+
+import (
+	"os"
+
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// SyncMode of writes.
+type SyncMode int
+
+const (
+	// NoSync leaves writes in the operating system's
+	// buffers, a machine crash can lose recent writes,
+	// a process crash can not.
+	NoSync SyncMode = 0
+	// SyncWrites flushes every applied mutation to
+	// disk before returning.
+	SyncWrites SyncMode = 1
+)
+
+// Cfg for LevelDB database.
+type Cfg struct {
+	BaseDir  string
+	Options  *opt.Options
+	FileMode os.FileMode
+	Sync     SyncMode
+	Encoding Encoding
+}
+
+// Driver name.
+func (c Cfg) Driver() string {
+	return DriverName
+}
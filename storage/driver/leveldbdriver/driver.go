@@ -0,0 +1,299 @@
+package leveldbdriver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"os"
+	"path"
+	"sync"
+	"time"
+
+	"github.com/lytics/flo/storage"
+	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/window"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+const (
+	// DriverName used for driver registration.
+	DriverName = "leveldb"
+	// stripes of key locks, mutations of keys in
+	// different stripes run concurrently.
+	stripes = 64
+)
+
+func init() {
+	storage.Register(DriverName, &drvr{})
+}
+
+type drvr struct{}
+
+func (d *drvr) Open(name string, cfg driver.Cfg) (driver.Conn, error) {
+	levelCfg, ok := cfg.(Cfg)
+	if !ok {
+		return nil, fmt.Errorf("leveldbdriver: unknown configuration type: %T", cfg)
+	}
+
+	// Location of database.
+	loc := path.Join(levelCfg.BaseDir, name)
+
+	// File mode of database directory.
+	mod := os.FileMode(0700)
+	if levelCfg.FileMode > 0 {
+		mod = levelCfg.FileMode
+	}
+	err := os.MkdirAll(loc, mod)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := leveldb.OpenFile(loc, levelCfg.Options)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encoding of values.
+	var enc Encoding = VectorEncoding{}
+	if levelCfg.Encoding != nil {
+		enc = levelCfg.Encoding
+	}
+
+	c := &Conn{
+		db:  db,
+		enc: enc,
+		wo:  &opt.WriteOptions{Sync: levelCfg.Sync == SyncWrites},
+	}
+	err = c.upgrade()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+type Conn struct {
+	db    *leveldb.DB
+	wo    *opt.WriteOptions
+	enc   Encoding
+	locks [stripes]sync.Mutex
+}
+
+// Apply the mutation, all changes to the row are written
+// in a single atomic batch. Reads see only committed
+// data, and a lock on the key's stripe serializes
+// mutations of the same key.
+func (c *Conn) Apply(ctx context.Context, key string, mut driver.Mutation) error {
+	mu := c.lock(key)
+	mu.Lock()
+	defer mu.Unlock()
+
+	b := new(leveldb.Batch)
+	rw := newRW(key, c.db, b, c.enc)
+
+	row, err := driver.NewRow(rw)
+	if err != nil {
+		return err
+	}
+
+	err = mut(row)
+	if err != nil {
+		return err
+	}
+
+	err = row.Flush()
+	if err != nil {
+		return err
+	}
+
+	if b.Len() == 0 {
+		return nil
+	}
+	return c.db.Write(b, c.wo)
+}
+
+func (c *Conn) Drain(ctx context.Context, keys []string, filter driver.Filter, sink driver.Sink) error {
+	snap, err := c.db.GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+
+	for _, key := range keys {
+		rw := newRW(key, snap, nil, c.enc)
+
+		row, err := driver.NewRow(rw)
+		if err != nil {
+			return err
+		}
+
+		for s, vs := range row.Windows() {
+			if !filter.Keep(key, s) {
+				continue
+			}
+			err := sink(ctx, s, key, vs)
+			if err != nil {
+				return err
+			}
+		}
+		if row.Err() != nil {
+			return row.Err()
+		}
+	}
+
+	return nil
+}
+
+func (c *Conn) Scan(ctx context.Context, sink driver.Sink) error {
+	snap, err := c.db.GetSnapshot()
+	if err != nil {
+		return err
+	}
+	defer snap.Release()
+
+	it := snap.NewIterator(nil, nil)
+	defer it.Release()
+
+	for it.Next() {
+		key, s, err := splitKey(it.Key())
+		if err != nil {
+			return err
+		}
+		vs, err := c.enc.Decode(it.Value())
+		if err != nil {
+			return err
+		}
+		err = sink(ctx, s, key, vs)
+		if err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
+
+func (c *Conn) Compact(ctx context.Context, before time.Time) error {
+	// Expired windows are collected by the stripe of
+	// their key, and deleted under the stripe's lock,
+	// so they do not interleave with mutations of
+	// those keys.
+	var expired [stripes][]kv
+
+	it := c.db.NewIterator(nil, nil)
+	for it.Next() {
+		key, s, err := splitKey(it.Key())
+		if err != nil {
+			it.Release()
+			return err
+		}
+		if s.Kind() != window.Time || !s.End().Before(before) {
+			continue
+		}
+		i := stripe(key)
+		expired[i] = append(expired[i], kv{
+			k: append([]byte(nil), it.Key()...),
+			v: append([]byte(nil), it.Value()...),
+		})
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	deleted := false
+	for i, kvs := range expired {
+		if len(kvs) == 0 {
+			continue
+		}
+		n, err := c.deleteUnchanged(&c.locks[i], kvs)
+		if err != nil {
+			return err
+		}
+		deleted = deleted || n > 0
+	}
+	if !deleted {
+		return nil
+	}
+
+	// Deletes are only tombstones until the
+	// tables holding them are compacted.
+	return c.db.CompactRange(util.Range{})
+}
+
+// kv pair read by a scan.
+type kv struct {
+	k, v []byte
+}
+
+// deleteUnchanged keys, holding the lock of their stripe.
+// Each key is read again, and kept if a mutation wrote
+// it since it was scanned. Returns the number deleted.
+func (c *Conn) deleteUnchanged(mu *sync.Mutex, kvs []kv) (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	b := new(leveldb.Batch)
+	for _, e := range kvs {
+		v, err := c.db.Get(e.k, nil)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+		if !bytes.Equal(v, e.v) {
+			continue
+		}
+		b.Delete(e.k)
+	}
+	if b.Len() == 0 {
+		return 0, nil
+	}
+	return b.Len(), c.db.Write(b, c.wo)
+}
+
+func (c *Conn) Close() error {
+	return c.db.Close()
+}
+
+// upgrade keys written with a legacy span key version,
+// so that the spans of a row sort in order again.
+func (c *Conn) upgrade() error {
+	b := new(leveldb.Batch)
+
+	it := c.db.NewIterator(nil, nil)
+	for it.Next() {
+		nk, err := upgradeKey(it.Key())
+		if err != nil {
+			it.Release()
+			return err
+		}
+		if nk != nil {
+			b.Put(nk, append([]byte(nil), it.Value()...))
+			b.Delete(append([]byte(nil), it.Key()...))
+		}
+	}
+	it.Release()
+	if err := it.Error(); err != nil {
+		return err
+	}
+
+	if b.Len() == 0 {
+		return nil
+	}
+	return c.db.Write(b, c.wo)
+}
+
+// lock of the stripe the key belongs to.
+func (c *Conn) lock(key string) *sync.Mutex {
+	return &c.locks[stripe(key)]
+}
+
+// stripe the key belongs to.
+func stripe(key string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return h.Sum32() % stripes
+}
@@ -0,0 +1,267 @@
+package leveldbdriver_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/internal/codec/protomessage"
+	"github.com/lytics/flo/storage"
+	"github.com/lytics/flo/storage/driver/leveldbdriver"
+	"github.com/lytics/flo/storage/driver/memdriver"
+	"github.com/lytics/flo/window"
+	"github.com/syndtr/goleveldb/leveldb"
+)
+
+var t0 = time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+
+// open a database in a temporary directory, which is
+// removed by the returned function.
+func open(t *testing.T, name string) (*storage.DB, string, func()) {
+	dir, err := ioutil.TempDir("", "flo-leveldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := storage.Open(name, leveldbdriver.Cfg{BaseDir: dir})
+	if err != nil {
+		os.RemoveAll(dir)
+		t.Fatal(err)
+	}
+	return db, dir, func() {
+		db.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func person(name string) []interface{} {
+	return []interface{}{&protomessage.Person{Name: name}}
+}
+
+// drain every window of the keys.
+func drain(t *testing.T, db *storage.DB, keys ...string) map[string]map[window.Span]string {
+	found := map[string]map[window.Span]string{}
+	err := db.Drain(context.Background(), keys, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		if found[key] == nil {
+			found[key] = map[window.Span]string{}
+		}
+		found[key][s] = vs[0].(*protomessage.Person).Name
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return found
+}
+
+func TestApplyDrainScan(t *testing.T) {
+	ctx := context.Background()
+	db, _, cleanup := open(t, "apply")
+	defer cleanup()
+
+	first := window.NewSpan(t0, t0.Add(1*time.Hour))
+	second := window.NewSpan(t0.Add(1*time.Hour), t0.Add(2*time.Hour))
+
+	err := db.Apply(ctx, "a", func(state window.State) error {
+		state.Set(first, person("first"))
+		state.Set(second, person("second"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The row of key "ab" shares the prefix of "a".
+	err = db.Apply(ctx, "ab", func(state window.State) error {
+		state.Set(first, person("other"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A failed mutation writes nothing.
+	err = db.Apply(ctx, "a", func(state window.State) error {
+		state.Del(first)
+		return os.ErrInvalid
+	})
+	if err != os.ErrInvalid {
+		t.Fatalf("expected mutation error, found: %v", err)
+	}
+
+	found := drain(t, db, "a")
+	if len(found) != 1 || len(found["a"]) != 2 || found["a"][first] != "first" || found["a"][second] != "second" {
+		t.Fatalf("expected both windows of key a, found: %v", found)
+	}
+
+	// Deleting a window only deletes that window.
+	err = db.Apply(ctx, "a", func(state window.State) error {
+		if state.Get(first) == nil {
+			t.Fatalf("expected window: %v", first)
+		}
+		state.Del(first)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	found = drain(t, db, "a", "ab")
+	if len(found["a"]) != 1 || found["a"][second] != "second" || found["ab"][first] != "other" {
+		t.Fatalf("expected second window of key a, and key ab, found: %v", found)
+	}
+
+	// Scan is what exports a snapshot of every key.
+	var buf bytes.Buffer
+	err = db.Export(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mem, err := storage.Open("scanned", memdriver.Cfg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = mem.Import(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanned := drain(t, mem, "a", "ab")
+	if len(scanned["a"]) != 1 || len(scanned["ab"]) != 1 {
+		t.Fatalf("expected one window of each key, found: %v", scanned)
+	}
+}
+
+func TestCompact(t *testing.T) {
+	ctx := context.Background()
+	db, _, cleanup := open(t, "compact")
+	defer cleanup()
+
+	old := window.NewSpan(t0, t0.Add(1*time.Hour))
+	recent := window.NewSpan(t0.Add(1*time.Hour), t0.Add(2*time.Hour))
+	ordinal := window.NewOrdinalSpan(0, 10).Close()
+
+	err := db.Apply(ctx, "key", func(state window.State) error {
+		state.Set(old, person("old"))
+		state.Set(recent, person("recent"))
+		state.Set(ordinal, person("ordinal"))
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Compact(ctx, t0.Add(90*time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Ordinal windows are never compacted.
+	found := drain(t, db, "key")["key"]
+	if len(found) != 2 || found[recent] == "" || found[ordinal] == "" {
+		t.Fatalf("expected recent and ordinal windows, found: %v", found)
+	}
+}
+
+func TestOverlappingOrdered(t *testing.T) {
+	ctx := context.Background()
+	db, _, cleanup := open(t, "overlapping")
+	defer cleanup()
+
+	// Spans before 1970 have negative starts, which
+	// must still sort before later spans.
+	t1970 := time.Unix(0, 0).UTC()
+	spans := []window.Span{
+		window.NewSpan(t1970.Add(-2*time.Hour), t1970.Add(-1*time.Hour)),
+		window.NewSpan(t1970.Add(-1*time.Hour), t1970),
+		window.NewSpan(t1970, t1970.Add(1*time.Hour)),
+		window.NewSpan(t1970.Add(1*time.Hour), t1970.Add(2*time.Hour)),
+		window.NewOrdinalSpan(0, 10),
+	}
+	err := db.Apply(ctx, "key", func(state window.State) error {
+		for i, s := range spans {
+			state.Set(s, person(string(rune('a'+i))))
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = db.Apply(ctx, "key", func(state window.State) error {
+		s := window.NewSpan(t1970.Add(-90*time.Minute), t1970.Add(30*time.Minute))
+		found := state.Overlapping(s)
+		if len(found) != 3 {
+			t.Fatalf("expected three overlapping windows, found: %v", found)
+		}
+		for _, s0 := range spans[:3] {
+			if _, ok := found[s0]; !ok {
+				t.Fatalf("expected overlapping window: %v, found: %v", s0, found)
+			}
+		}
+
+		found = state.Overlapping(window.NewOrdinalSpan(5, 6))
+		if len(found) != 1 {
+			t.Fatalf("expected the ordinal window, found: %v", found)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestUpgradeLegacyKeys(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flo-leveldb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Legacy keys hold seconds: <key>@<start>:<end>
+	sk := make([]byte, window.SpanKeySize(window.SpanKeyV1))
+	binary.BigEndian.PutUint64(sk[0:], uint64(t0.Unix()))
+	sk[8] = ':'
+	binary.BigEndian.PutUint64(sk[9:], uint64(t0.Add(1*time.Hour).Unix()))
+	legacy := append([]byte("key@"), sk...)
+
+	vb, err := leveldbdriver.VectorEncoding{}.Encode(person("legacy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := leveldb.OpenFile(path.Join(dir, "upgrade"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = raw.Put(legacy, vb, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw.Close()
+
+	db, err := storage.Open("upgrade", leveldbdriver.Cfg{BaseDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := window.NewSpan(t0, t0.Add(1*time.Hour))
+	found := drain(t, db, "key")["key"]
+	if len(found) != 1 || found[s] != "legacy" {
+		t.Fatalf("expected upgraded window: %v, found: %v", s, found)
+	}
+	db.Close()
+
+	// Only the upgraded key remains.
+	raw, err = leveldb.OpenFile(path.Join(dir, "upgrade"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer raw.Close()
+	ok, err := raw.Has(legacy, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ok {
+		t.Fatal("expected legacy key to be deleted")
+	}
+}
@@ -0,0 +1,62 @@
+package leveldbdriver
+
+import (
+	"fmt"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/lytics/flo/internal/codec"
+)
+
+// Encoding of the values of a window. Each datum must
+// be of a type registered with flo.RegisterMsg.
+type Encoding interface {
+	Encode(vs []interface{}) ([]byte, error)
+	Decode(vb []byte) ([]interface{}, error)
+}
+
+// VectorEncoding encodes values as a protobuf vector
+// of datums of a single registered type. It is the
+// default, and the same format used by boltdriver.
+type VectorEncoding struct{}
+
+// Encode values.
+func (VectorEncoding) Encode(vs []interface{}) ([]byte, error) {
+	vec := &Vector{}
+	dataType := ""
+	for _, v := range vs {
+		datumType, datum, err := codec.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("leveldbdriver: failed to encode: %v", err)
+		}
+		if dataType == "" {
+			dataType = datumType
+		}
+		if dataType != datumType {
+			return nil, fmt.Errorf("leveldbdriver: invalid encoded data type: %v, expected: %v", datumType, dataType)
+		}
+		vec.Data = append(vec.Data, datum)
+	}
+	vec.DataType = dataType
+
+	return proto.Marshal(vec)
+}
+
+// Decode values.
+func (VectorEncoding) Decode(vb []byte) ([]interface{}, error) {
+	vec := &Vector{}
+	err := proto.Unmarshal(vb, vec)
+	if err != nil {
+		return nil, err
+	}
+
+	res := []interface{}{}
+	for _, e := range vec.Data {
+		v, err := codec.Unmarshal(e, vec.DataType)
+		if err != nil {
+			return nil, fmt.Errorf("leveldbdriver: failed to decode: %v", err)
+		}
+		res = append(res, v)
+	}
+
+	return res, nil
+}
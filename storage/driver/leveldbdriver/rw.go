@@ -0,0 +1,126 @@
+package leveldbdriver
+
+import (
+	"github.com/lytics/flo/window"
+	"github.com/syndtr/goleveldb/leveldb"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
+)
+
+// reader of either the database or one of its snapshots.
+type reader interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+func newRW(key string, r reader, b *leveldb.Batch, enc Encoding) *rw {
+	return &rw{
+		r:      r,
+		b:      b,
+		enc:    enc,
+		prefix: []byte(key),
+	}
+}
+
+// rw reads from r, and writes into the batch b,
+// which is applied as a whole by the caller.
+type rw struct {
+	r      reader
+	b      *leveldb.Batch
+	enc    Encoding
+	prefix []byte
+}
+
+func (rw *rw) DelSpan(s window.Span) error {
+	k, err := encodeKey(s, rw)
+	if err != nil {
+		return err
+	}
+	rw.b.Delete(k)
+	return nil
+}
+
+func (rw *rw) PutSpan(s window.Span, vs []interface{}) error {
+	k, err := encodeKey(s, rw)
+	if err != nil {
+		return err
+	}
+	v, err := rw.enc.Encode(vs)
+	if err != nil {
+		return err
+	}
+	rw.b.Put(k, v)
+	return nil
+}
+
+func (rw *rw) GetSpan(s window.Span) ([]interface{}, error) {
+	k, err := encodeKey(s, rw)
+	if err != nil {
+		return nil, err
+	}
+	vb, err := rw.r.Get(k, nil)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return rw.enc.Decode(vb)
+}
+
+func (rw *rw) Overlapping(s window.Span) (map[window.Span][]interface{}, error) {
+	snap := map[window.Span][]interface{}{}
+	err := rw.scan(func(k window.Span, vb []byte) (bool, error) {
+		// Time spans sort first, by start, so
+		// no later span can overlap s.
+		if s.Kind() == window.Time && !k.Start().Before(s.End()) {
+			return false, nil
+		}
+		if !k.Overlap(s) {
+			return true, nil
+		}
+		v, err := rw.enc.Decode(vb)
+		if err != nil {
+			return false, err
+		}
+		snap[k] = v
+		return true, nil
+	})
+	return snap, err
+}
+
+func (rw *rw) Windows() (map[window.Span][]interface{}, error) {
+	snap := map[window.Span][]interface{}{}
+	err := rw.scan(func(k window.Span, vb []byte) (bool, error) {
+		v, err := rw.enc.Decode(vb)
+		if err != nil {
+			return false, err
+		}
+		snap[k] = v
+		return true, nil
+	})
+	return snap, err
+}
+
+// scan the spans of the row in order of their start,
+// until f returns false or an error.
+func (rw *rw) scan(f func(k window.Span, vb []byte) (bool, error)) error {
+	it := rw.r.NewIterator(util.BytesPrefix(rowPrefix(rw)), nil)
+	defer it.Release()
+
+	for it.Next() {
+		k, err := decodeKey(it.Key(), rw)
+		if err != nil {
+			return err
+		}
+		more, err := f(k, it.Value())
+		if err != nil {
+			return err
+		}
+		if !more {
+			return nil
+		}
+	}
+	return it.Error()
+}
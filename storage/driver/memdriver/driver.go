@@ -3,9 +3,11 @@ package memdriver
 import (
 	"context"
 	"sync"
+	"time"
 
 	"github.com/lytics/flo/storage"
 	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/window"
 )
 
 const DriverName = "mem"
@@ -28,15 +30,7 @@ type Conn struct {
 }
 
 func (c *Conn) Apply(ctx context.Context, key string, mut driver.Mutation) error {
-	c.mu.Lock()
-	rw, ok := c.data[key]
-	if !ok {
-		rw = newRW(key)
-		c.data[key] = rw
-	}
-	c.mu.Unlock()
-
-	rw.mu.Lock()
+	rw := c.row(key)
 	defer rw.mu.Unlock()
 
 	row, err := driver.NewRow(rw)
@@ -52,7 +46,28 @@ func (c *Conn) Apply(ctx context.Context, key string, mut driver.Mutation) error
 	return row.Flush()
 }
 
-func (c *Conn) Drain(ctx context.Context, keys []string, sink driver.Sink) error {
+// row of the key, locked. A row deleted by compaction
+// while waiting for its lock is replaced, so no mutation
+// is applied to a row which is gone.
+func (c *Conn) row(key string) *rw {
+	for {
+		c.mu.Lock()
+		rw, ok := c.data[key]
+		if !ok {
+			rw = newRW(key)
+			c.data[key] = rw
+		}
+		c.mu.Unlock()
+
+		rw.mu.Lock()
+		if !rw.deleted {
+			return rw
+		}
+		rw.mu.Unlock()
+	}
+}
+
+func (c *Conn) Drain(ctx context.Context, keys []string, filter driver.Filter, sink driver.Sink) error {
 	snap := map[string]*rw{}
 
 	c.mu.Lock()
@@ -69,6 +84,9 @@ func (c *Conn) Drain(ctx context.Context, keys []string, sink driver.Sink) error
 		defer rw.mu.Unlock()
 
 		for s, vs := range rw.windows {
+			if !filter.Keep(rw.key, s) {
+				continue
+			}
 			err := sink(ctx, s, rw.key, vs)
 			if err != nil {
 				return err
@@ -87,3 +105,39 @@ func (c *Conn) Drain(ctx context.Context, keys []string, sink driver.Sink) error
 
 	return nil
 }
+
+func (c *Conn) Scan(ctx context.Context, sink driver.Sink) error {
+	c.mu.Lock()
+	keys := make([]string, 0, len(c.data))
+	for k := range c.data {
+		keys = append(keys, k)
+	}
+	c.mu.Unlock()
+
+	return c.Drain(ctx, keys, nil, sink)
+}
+
+func (c *Conn) Compact(ctx context.Context, before time.Time) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for key, rw := range c.data {
+		rw.mu.Lock()
+		for s := range rw.windows {
+			if s.Kind() == window.Time && s.End().Before(before) {
+				delete(rw.windows, s)
+			}
+		}
+		if len(rw.windows) == 0 {
+			rw.deleted = true
+			delete(c.data, key)
+		}
+		rw.mu.Unlock()
+	}
+
+	return nil
+}
+
+func (c *Conn) Close() error {
+	return nil
+}
@@ -0,0 +1,68 @@
+package memdriver
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/window"
+)
+
+func TestApplyDuringCompact(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	expired := window.NewSpan(t0, t0.Add(1*time.Minute))
+	fresh := window.NewSpan(t0.Add(1*time.Hour), t0.Add(2*time.Hour))
+
+	put := func(s window.Span) driver.Mutation {
+		return func(state window.State) error {
+			state.Set(s, []interface{}{1})
+			return nil
+		}
+	}
+
+	ctx := context.Background()
+	c := &Conn{data: map[string]*rw{}}
+	err := c.Apply(ctx, "key", put(expired))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Hold the row, so the apply and the compaction
+	// both wait for it, either may get it first.
+	held := c.data["key"]
+	held.mu.Lock()
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		if err := c.Apply(ctx, "key", put(fresh)); err != nil {
+			t.Error(err)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	go func() {
+		defer wg.Done()
+		if err := c.Compact(ctx, t0.Add(30*time.Minute)); err != nil {
+			t.Error(err)
+		}
+	}()
+	time.Sleep(10 * time.Millisecond)
+	held.mu.Unlock()
+	wg.Wait()
+
+	// The window applied is never lost with the row.
+	var found []window.Span
+	err = c.Drain(ctx, []string{"key"}, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		found = append(found, s)
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 1 || found[0] != fresh {
+		t.Fatalf("expected the applied window, found: %v", found)
+	}
+}
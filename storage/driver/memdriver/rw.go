@@ -17,6 +17,7 @@ type rw struct {
 	mu sync.Mutex
 	key string
 	windows map[window.Span][]interface{}
+	deleted bool // Deleted by compaction.
 }
 
 func (rw *rw) DelSpan(s window.Span) error {
@@ -29,6 +30,20 @@ func (rw *rw) PutSpan(s window.Span, vs []interface{}) error {
 	return nil
 }
 
+func (rw *rw) GetSpan(s window.Span) ([]interface{}, error) {
+	return rw.windows[s], nil
+}
+
+func (rw *rw) Overlapping(s window.Span) (map[window.Span][]interface{}, error) {
+	snap := map[window.Span][]interface{}{}
+	for k, v := range rw.windows {
+		if k.Overlap(s) {
+			snap[k] = v
+		}
+	}
+	return snap, nil
+}
+
 func (rw *rw) Windows() (map[window.Span][]interface{}, error) {
 	snap := map[window.Span][]interface{}{}
 	for k, v := range rw.windows {
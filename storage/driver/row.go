@@ -4,14 +4,13 @@ import (
 	"github.com/lytics/flo/window"
 )
 
+// NewRow for the read-writer. Windows are read lazily,
+// only when, and to the extent that, a mutation asks
+// for them.
 func NewRow(rw ReadWriter) (*Row, error) {
-	windows, err := rw.Windows()
-	if err != nil {
-		return nil, err
-	}
 	return &Row{
 		rw:      rw,
-		windows: windows,
+		windows: map[window.Span][]interface{}{},
 		deletes: map[window.Span]bool{},
 		updates: map[window.Span][]interface{}{},
 	}, nil
@@ -20,12 +19,15 @@ func NewRow(rw ReadWriter) (*Row, error) {
 // Row data for a key.
 type Row struct {
 	rw      ReadWriter
+	err     error
+	loaded  bool
 	deletes map[window.Span]bool
 	updates map[window.Span][]interface{}
 	windows map[window.Span][]interface{}
 }
 
 func (r *Row) Del(k window.Span) {
+	delete(r.updates, k)
 	r.deletes[k] = true
 }
 
@@ -37,7 +39,19 @@ func (r *Row) Get(k window.Span) []interface{} {
 	if ok {
 		return v
 	}
-	return r.windows[k]
+	v, ok = r.windows[k]
+	if ok || r.loaded {
+		return v
+	}
+	v, err := r.rw.GetSpan(k)
+	if err != nil {
+		r.fail(err)
+		return nil
+	}
+	// Missing windows are remembered too, as
+	// nil, so they are not read repeatedly.
+	r.windows[k] = v
+	return v
 }
 
 func (r *Row) Set(k window.Span, v []interface{}) {
@@ -45,24 +59,45 @@ func (r *Row) Set(k window.Span, v []interface{}) {
 	r.updates[k] = v
 }
 
-func (r *Row) Windows() map[window.Span][]interface{} {
-	ss := map[window.Span][]interface{}{}
-	for k, v := range r.updates {
-		ss[k] = v
-	}
-	for k, v := range r.windows {
-		if r.deletes[k] {
-			continue
+func (r *Row) Overlapping(s window.Span) map[window.Span][]interface{} {
+	if !r.loaded {
+		windows, err := r.rw.Overlapping(s)
+		if err != nil {
+			r.fail(err)
+			return nil
 		}
-		if _, ok := r.updates[k]; ok {
-			continue
+		for k, v := range windows {
+			r.windows[k] = v
 		}
-		ss[k] = v
 	}
-	return ss
+	return r.view(func(k window.Span) bool { return k.Overlap(s) })
+}
+
+func (r *Row) Windows() map[window.Span][]interface{} {
+	if !r.loaded {
+		windows, err := r.rw.Windows()
+		if err != nil {
+			r.fail(err)
+			return nil
+		}
+		r.windows = windows
+		r.loaded = true
+	}
+	return r.view(func(window.Span) bool { return true })
+}
+
+// Err of reading windows, if any. Reads happen lazily
+// inside of Get, Overlapping and Windows, which have no
+// error return, so the first error is kept, and also
+// returned by Flush.
+func (r *Row) Err() error {
+	return r.err
 }
 
 func (r *Row) Flush() error {
+	if r.err != nil {
+		return r.err
+	}
 	for k := range r.deletes {
 		err := r.rw.DelSpan(k)
 		if err != nil {
@@ -77,3 +112,30 @@ func (r *Row) Flush() error {
 	}
 	return nil
 }
+
+// view of the read windows merged with the updates and
+// deletes, filtered to the spans matching f.
+func (r *Row) view(f func(window.Span) bool) map[window.Span][]interface{} {
+	ss := map[window.Span][]interface{}{}
+	for k, v := range r.updates {
+		if f(k) {
+			ss[k] = v
+		}
+	}
+	for k, v := range r.windows {
+		if v == nil || r.deletes[k] || !f(k) {
+			continue
+		}
+		if _, ok := r.updates[k]; ok {
+			continue
+		}
+		ss[k] = v
+	}
+	return ss
+}
+
+func (r *Row) fail(err error) {
+	if r.err == nil {
+		r.err = err
+	}
+}
@@ -0,0 +1,106 @@
+package driver
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/window"
+)
+
+// countingRW counts the reads made against it.
+type countingRW struct {
+	gets        int
+	overlapping int
+	windows     int
+	data        map[window.Span][]interface{}
+}
+
+func (rw *countingRW) DelSpan(s window.Span) error {
+	delete(rw.data, s)
+	return nil
+}
+
+func (rw *countingRW) PutSpan(s window.Span, vs []interface{}) error {
+	rw.data[s] = vs
+	return nil
+}
+
+func (rw *countingRW) GetSpan(s window.Span) ([]interface{}, error) {
+	rw.gets++
+	return rw.data[s], nil
+}
+
+func (rw *countingRW) Overlapping(s window.Span) (map[window.Span][]interface{}, error) {
+	rw.overlapping++
+	snap := map[window.Span][]interface{}{}
+	for k, v := range rw.data {
+		if k.Overlap(s) {
+			snap[k] = v
+		}
+	}
+	return snap, nil
+}
+
+func (rw *countingRW) Windows() (map[window.Span][]interface{}, error) {
+	rw.windows++
+	snap := map[window.Span][]interface{}{}
+	for k, v := range rw.data {
+		snap[k] = v
+	}
+	return snap, nil
+}
+
+func TestRowLazyReads(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s0 := window.NewSpan(t0, t0.Add(1*time.Hour))
+	s1 := window.NewSpan(t0.Add(1*time.Hour), t0.Add(2*time.Hour))
+	s2 := window.NewSpan(t0.Add(2*time.Hour), t0.Add(3*time.Hour))
+
+	rw := &countingRW{data: map[window.Span][]interface{}{
+		s0: {0},
+		s1: {1},
+		s2: {2},
+	}}
+
+	row, err := NewRow(rw)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Repeated gets of the same span only
+	// read the span once.
+	row.Get(s1)
+	row.Get(s1)
+	if rw.gets != 1 || rw.windows != 0 {
+		t.Fatalf("expected one get and no full reads, got: %v gets, %v full reads", rw.gets, rw.windows)
+	}
+
+	// Overlapping only returns the overlapping
+	// windows, including pending updates.
+	row.Set(s2, []interface{}{3})
+	ss := row.Overlapping(window.NewSpan(t0.Add(90*time.Minute), t0.Add(150*time.Minute)))
+	if len(ss) != 2 || ss[s2][0].(int) != 3 {
+		t.Fatalf("expected windows s1 and updated s2, got: %v", ss)
+	}
+	if rw.windows != 0 {
+		t.Fatal("expected no full reads")
+	}
+
+	// Deleted windows are hidden even if
+	// they were read before.
+	row.Del(s1)
+	if row.Get(s1) != nil {
+		t.Fatal("expected deleted window")
+	}
+	if len(row.Windows()) != 2 {
+		t.Fatalf("expected two windows, got: %v", row.Windows())
+	}
+
+	err = row.Flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := rw.data[s1]; ok {
+		t.Fatal("expected deleted window to be flushed")
+	}
+}
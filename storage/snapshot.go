@@ -0,0 +1,246 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/lytics/flo/internal/codec"
+	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/window"
+)
+
+// snapshotMagic starts every snapshot stream, the last
+// byte is the version of the format. Version 1 streams
+// hold spans in seconds, version 2 in milliseconds, and
+// version 3 adds the kind of span.
+var snapshotMagic = []byte("flo-snapshot\x03")
+
+// Limits of a snapshot record, so a corrupt stream fails
+// to import rather than exhausting memory.
+const (
+	maxSnapshotBytes  = 64 << 20 // Of a key, data type or datum.
+	maxSnapshotValues = 1 << 24  // Of a window.
+)
+
+// Export every key, span and value in the database to w.
+// The format is independent of any driver, values are
+// encoded with the codec registry, so their types must
+// be registered by the exporting process.
+//
+// The stream is the magic header followed by records:
+//
+//	<key> <start> <end> <kind> <data type> <count> <datum>...
+//
+// Where strings and datums are uvarint length prefixed,
+// and the start, end and kind of the span are varints.
+func (db *DB) Export(ctx context.Context, w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	_, err := bw.Write(snapshotMagic)
+	if err != nil {
+		return err
+	}
+	err = db.conn.Scan(ctx, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		return writeRecord(bw, key, s, vs)
+	})
+	if err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// Import a stream produced by Export. Existing values of
+// spans found in the stream are overwritten, other spans
+// are left as is. Value types must be registered with
+// the codec registry by the importing process.
+func (db *DB) Import(ctx context.Context, r io.Reader) error {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(snapshotMagic))
+	_, err := io.ReadFull(br, magic)
+	if err != nil {
+		return fmt.Errorf("storage: failed to read snapshot header: %v", err)
+	}
+	n := len(snapshotMagic) - 1
+	if !bytes.Equal(magic[:n], snapshotMagic[:n]) {
+		return fmt.Errorf("storage: not a snapshot or unsupported version")
+	}
+	version := magic[n]
+	if version < 1 || version > 3 {
+		return fmt.Errorf("storage: not a snapshot or unsupported version")
+	}
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+		key, s, vs, err := readRecord(br, version)
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("storage: failed to read snapshot: %v", err)
+		}
+		if version == 1 {
+			s = window.Span{s[0] * 1000, s[1] * 1000}
+		}
+		err = db.Apply(ctx, key, func(state window.State) error {
+			state.Set(s, vs)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Copy every key, span and value of src into dst.
+func Copy(ctx context.Context, dst, src *DB) error {
+	return src.conn.Scan(ctx, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		return dst.Apply(ctx, key, func(state window.State) error {
+			state.Set(s, vs)
+			return nil
+		})
+	})
+}
+
+// Migrate the database with the given name from one
+// driver configuration to another, for example:
+//
+//	storage.Migrate(ctx, name, boltdriver.Cfg{...}, badgerdriver.Cfg{...})
+//
+// Graphs using the database must not be running.
+func Migrate(ctx context.Context, name string, from, to driver.Cfg) error {
+	src, err := Open(name, from)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := Open(name, to)
+	if err != nil {
+		return err
+	}
+	defer dst.Close()
+
+	return Copy(ctx, dst, src)
+}
+
+func writeRecord(w io.Writer, key string, s window.Span, vs []interface{}) error {
+	dataType := ""
+	data := make([][]byte, 0, len(vs))
+	for _, v := range vs {
+		datumType, datum, err := codec.Marshal(v)
+		if err != nil {
+			return fmt.Errorf("storage: failed to encode: %v", err)
+		}
+		if dataType == "" {
+			dataType = datumType
+		}
+		if dataType != datumType {
+			return fmt.Errorf("storage: invalid encoded data type: %v, expected: %v", datumType, dataType)
+		}
+		data = append(data, datum)
+	}
+
+	var rec []byte
+	buf := make([]byte, binary.MaxVarintLen64)
+	putUvarint := func(v uint64) {
+		n := binary.PutUvarint(buf, v)
+		rec = append(rec, buf[:n]...)
+	}
+	putVarint := func(v int64) {
+		n := binary.PutVarint(buf, v)
+		rec = append(rec, buf[:n]...)
+	}
+	putBytes := func(b []byte) {
+		putUvarint(uint64(len(b)))
+		rec = append(rec, b...)
+	}
+
+	putBytes([]byte(key))
+	putVarint(s[0])
+	putVarint(s[1])
+	putVarint(s[2])
+	putBytes([]byte(dataType))
+	putUvarint(uint64(len(data)))
+	for _, datum := range data {
+		putBytes(datum)
+	}
+
+	_, err := w.Write(rec)
+	return err
+}
+
+func readRecord(r *bufio.Reader, version byte) (string, window.Span, []interface{}, error) {
+	getBytes := func() ([]byte, error) {
+		n, err := binary.ReadUvarint(r)
+		if err != nil {
+			return nil, err
+		}
+		if n > maxSnapshotBytes {
+			return nil, fmt.Errorf("length: %v exceeds max: %v", n, maxSnapshotBytes)
+		}
+		// Read in bounded chunks, so a truncated stream
+		// fails before the whole length is allocated.
+		var buf bytes.Buffer
+		_, err = io.CopyN(&buf, r, int64(n))
+		return buf.Bytes(), unexpectedEOF(err)
+	}
+
+	key, err := getBytes()
+	if err != nil {
+		// A clean end of stream is only
+		// valid between records.
+		return "", window.Span{}, nil, err
+	}
+	start, err := binary.ReadVarint(r)
+	if err != nil {
+		return "", window.Span{}, nil, unexpectedEOF(err)
+	}
+	end, err := binary.ReadVarint(r)
+	if err != nil {
+		return "", window.Span{}, nil, unexpectedEOF(err)
+	}
+	var kind int64
+	if version >= 3 {
+		kind, err = binary.ReadVarint(r)
+		if err != nil {
+			return "", window.Span{}, nil, unexpectedEOF(err)
+		}
+	}
+	dataType, err := getBytes()
+	if err != nil {
+		return "", window.Span{}, nil, unexpectedEOF(err)
+	}
+	count, err := binary.ReadUvarint(r)
+	if err != nil {
+		return "", window.Span{}, nil, unexpectedEOF(err)
+	}
+	if count > maxSnapshotValues {
+		return "", window.Span{}, nil, fmt.Errorf("count: %v exceeds max: %v", count, maxSnapshotValues)
+	}
+	var vs []interface{}
+	for i := uint64(0); i < count; i++ {
+		datum, err := getBytes()
+		if err != nil {
+			return "", window.Span{}, nil, unexpectedEOF(err)
+		}
+		v, err := codec.Unmarshal(datum, string(dataType))
+		if err != nil {
+			return "", window.Span{}, nil, fmt.Errorf("failed to decode: %v", err)
+		}
+		vs = append(vs, v)
+	}
+	return string(key), window.Span{start, end, kind}, vs, nil
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -0,0 +1,221 @@
+package storage_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/internal/codec"
+	"github.com/lytics/flo/internal/codec/protomessage"
+	"github.com/lytics/flo/storage"
+	"github.com/lytics/flo/storage/driver"
+	"github.com/lytics/flo/storage/driver/boltdriver"
+	"github.com/lytics/flo/storage/driver/leveldbdriver"
+	"github.com/lytics/flo/storage/driver/memdriver"
+	"github.com/lytics/flo/window"
+)
+
+func TestExportImport(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flo-snapshot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	cfgs := map[string]driver.Cfg{
+		"mem":     memdriver.Cfg{},
+		"bolt":    boltdriver.Cfg{BaseDir: dir},
+		"leveldb": leveldbdriver.Cfg{BaseDir: dir},
+	}
+	for name, cfg := range cfgs {
+		t.Run(name, func(t *testing.T) {
+			exportImport(t, "src-"+name, "dst-"+name, cfg)
+		})
+	}
+}
+
+// exportImport round trips a snapshot between two
+// databases of the driver configuration.
+func exportImport(t *testing.T, srcName, dstName string, cfg driver.Cfg) {
+	err := codec.Register(protomessage.Person{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	span := window.NewSpan(time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC), time.Date(2017, 01, 01, 14, 0, 0, 0, time.UTC))
+	closed := window.NewOrdinalSpan(0, 2).Close()
+
+	src, err := storage.Open(srcName, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	for _, name := range []string{"ann", "bob"} {
+		err := src.Apply(ctx, name, func(state window.State) error {
+			state.Set(span, []interface{}{&protomessage.Person{Name: name}})
+			state.Set(closed, []interface{}{&protomessage.Person{Name: name}, &protomessage.Person{Name: name}})
+			return nil
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var buf bytes.Buffer
+	err = src.Export(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := storage.Open(dstName, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	err = dst.Import(ctx, &buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Both the time and the ordinal spans survive.
+	found := map[string]int{}
+	err = dst.Drain(ctx, []string{"ann", "bob"}, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		switch {
+		case s.Equal(span) && len(vs) == 1:
+		case s.Equal(closed) && len(vs) == 2:
+		default:
+			t.Fatalf("expected span: %v or %v, got: %v, with: %v", span, closed, s, vs)
+		}
+		for _, v := range vs {
+			if v.(*protomessage.Person).Name != key {
+				t.Fatalf("expected person: %v, got: %v", key, vs)
+			}
+		}
+		found[key]++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found) != 2 || found["ann"] != 2 || found["bob"] != 2 {
+		t.Fatalf("expected two spans of two keys, got: %v", found)
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "flo-migrate")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	err = codec.Register(protomessage.Person{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	span := window.NewSpan(time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC), time.Date(2017, 01, 01, 14, 0, 0, 0, time.UTC))
+	// The drivers lay out their files differently, so
+	// each gets a directory of its own.
+	from := boltdriver.Cfg{BaseDir: path.Join(dir, "bolt")}
+	to := leveldbdriver.Cfg{BaseDir: path.Join(dir, "leveldb")}
+	for _, base := range []string{from.BaseDir, to.BaseDir} {
+		if err := os.Mkdir(base, 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	src, err := storage.Open("graph", from)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = src.Apply(ctx, "ann", func(state window.State) error {
+		state.Set(span, []interface{}{&protomessage.Person{Name: "ann"}})
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	src.Close()
+
+	err = storage.Migrate(ctx, "graph", from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dst, err := storage.Open("graph", to)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	n := 0
+	err = dst.Drain(ctx, []string{"ann"}, nil, func(ctx context.Context, s window.Span, key string, vs []interface{}) error {
+		if !s.Equal(span) || len(vs) != 1 || vs[0].(*protomessage.Person).Name != "ann" {
+			t.Fatalf("expected person ann in span: %v, got: %v, in: %v", span, vs, s)
+		}
+		n++
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Fatalf("expected one span, got: %v", n)
+	}
+}
+
+func TestImportInvalid(t *testing.T) {
+	db, err := storage.Open("invalid", memdriver.Cfg{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = db.Import(context.Background(), bytes.NewBufferString("not a snapshot"))
+	if err == nil {
+		t.Fatal("expected error")
+	}
+}
+
+func TestImportCorrupt(t *testing.T) {
+	uvarint := func(v uint64) []byte {
+		buf := make([]byte, binary.MaxVarintLen64)
+		return buf[:binary.PutUvarint(buf, v)]
+	}
+	varint := func(v int64) []byte {
+		buf := make([]byte, binary.MaxVarintLen64)
+		return buf[:binary.PutVarint(buf, v)]
+	}
+	record := func(parts ...[]byte) []byte {
+		rec := []byte("flo-snapshot\x03")
+		for _, p := range parts {
+			rec = append(rec, p...)
+		}
+		return rec
+	}
+	span := append(append(varint(0), varint(1000)...), varint(0)...)
+
+	tests := map[string][]byte{
+		"huge key":        record(uvarint(1 << 62)),
+		"truncated key":   record(uvarint(1<<20), []byte("key")),
+		"huge count":      record(uvarint(3), []byte("key"), span, uvarint(0), uvarint(1<<62)),
+		"truncated datum": record(uvarint(3), []byte("key"), span, uvarint(0), uvarint(1), uvarint(1<<20), []byte{1}),
+		"truncated span":  record(uvarint(3), []byte("key"), varint(0)),
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			db, err := storage.Open("corrupt", memdriver.Cfg{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = db.Import(context.Background(), bytes.NewReader(data))
+			if err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
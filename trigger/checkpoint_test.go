@@ -0,0 +1,82 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/window"
+)
+
+func TestDormantCheckpoint(t *testing.T) {
+	d := WhenDormant(1 * time.Minute)
+	d.Modified("key", nil, nil)
+
+	data, err := d.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A restarted trigger still knows the key, and
+	// when it was last modified.
+	r := WhenDormant(1 * time.Minute)
+	err = r.Restore(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.modified["key"].Equal(d.modified["key"]) {
+		t.Fatalf("expected modified: %v, found: %v", d.modified["key"], r.modified["key"])
+	}
+}
+
+func TestClosedCheckpoint(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s := window.NewSpan(t0, t0.Add(1*time.Minute))
+
+	c := WhenClosed(1 * time.Minute)
+	c.Modified("key", nil, map[window.Span][]interface{}{s: nil})
+	data, err := c.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := WhenClosed(1 * time.Minute)
+	err = r.Restore(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kss := r.closed(t0.Add(2 * time.Minute))
+	if len(kss) != 1 || kss[0] != (KeySpan{Key: "key", Span: s}) {
+		t.Fatalf("expected restored window closed, found: %v", kss)
+	}
+}
+
+func TestPredicateCheckpoint(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s := window.NewSpan(t0, t0.Add(1*time.Minute))
+	always := func(key string, span window.Span, vs []interface{}) bool {
+		return true
+	}
+
+	w := When(always).Debounce(1 * time.Hour)
+	w.Modified("key", nil, map[window.Span][]interface{}{s: nil})
+	data, err := w.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := When(always).Debounce(1 * time.Hour)
+	err = r.Restore(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kss := r.take(time.Now())
+	if len(kss) != 1 || kss[0] != (KeySpan{Key: "key", Span: s}) {
+		t.Fatalf("expected restored pending window, found: %v", kss)
+	}
+
+	// Still debounced after the restore.
+	r.Modified("key", nil, map[window.Span][]interface{}{s: nil})
+	if kss := r.take(time.Now()); len(kss) != 0 {
+		t.Fatalf("expected debounced window, found: %v", kss)
+	}
+}
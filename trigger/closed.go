@@ -0,0 +1,215 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/window"
+)
+
+// WhenClosed emits the windows of keys once they close,
+// rather than every window of the key. A time window is
+// closed when its end plus the lag has passed, measured
+// in processing time, an ordinal window when the window
+// closes it. At end of stream every window is closed,
+// including open ordinal windows.
+func WhenClosed(lag time.Duration) *Closed {
+	return &Closed{
+		stop:     make(chan struct{}),
+		failed:   make(chan error, 1),
+		lag:      lag,
+		modified: map[KeySpan]bool{},
+		open:     map[string]window.Span{},
+		logger:   log.New(os.Stderr, "closed-trigger: ", log.LstdFlags),
+	}
+}
+
+// Closed windows trigger.
+type Closed struct {
+	discarder
+	mu       sync.Mutex
+	stop     chan struct{}
+	failed   chan error // Failure to signal the end of stream.
+	lag      time.Duration
+	logger   *log.Logger
+	signal   func([]KeySpan) error
+	modified map[KeySpan]bool
+	open     map[string]window.Span // Open ordinal window of keys.
+}
+
+// Heuristic of end of stream closes every window. A
+// failure to signal them is returned by Start.
+func (t *Closed) Heuristic(h *progress.Heuristic) {
+	if !h.EOS {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if (len(t.modified) == 0 && len(t.open) == 0) || t.signal == nil {
+		return
+	}
+
+	kss := []KeySpan{}
+	for ks := range t.modified {
+		kss = append(kss, ks)
+	}
+	for key, s := range t.open {
+		kss = append(kss, KeySpan{Key: key, Span: s})
+	}
+	t.modified = map[KeySpan]bool{}
+	t.open = map[string]window.Span{}
+
+	err := t.signal(kss)
+	if err != nil {
+		select {
+		case t.failed <- err:
+		default:
+		}
+	}
+}
+
+// Modified key, vs are the windows holding v. The open
+// ordinal window of a key is only tracked until it is
+// closed, since closing it changes its span.
+func (t *Closed) Modified(key string, v interface{}, vs map[window.Span][]interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for s := range vs {
+		switch s.Kind() {
+		case window.Ordinal:
+			t.open[key] = s
+			continue
+		case window.Closed:
+			if open, ok := t.open[key]; ok && open[0] == s[0] {
+				delete(t.open, key)
+			}
+		}
+		t.modified[KeySpan{Key: key, Span: s}] = true
+	}
+	return nil
+}
+
+// StartSpans of the trigger, signalling closed windows
+// with the signal function.
+func (t *Closed) StartSpans(signal func(kss []KeySpan) error) error {
+	t.mu.Lock()
+	t.signal = signal
+	t.mu.Unlock()
+
+	freq := t.lag / 10
+	if freq < 100*time.Millisecond {
+		freq = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.stop:
+			return nil
+		case err := <-t.failed:
+			return err
+		case now := <-ticker.C:
+			kss := t.closed(now)
+			if len(kss) == 0 {
+				continue
+			}
+			err := signal(kss)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Start the trigger, signalling the keys of closed
+// windows, for use where windows cannot be signaled.
+func (t *Closed) Start(signal func(keys []string) error) error {
+	return t.StartSpans(func(kss []KeySpan) error {
+		return signal(keysOf(kss))
+	})
+}
+
+// closed windows at the processing time now, which are
+// no longer tracked once returned.
+func (t *Closed) closed(now time.Time) []KeySpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var kss []KeySpan
+	for ks := range t.modified {
+		if ks.Span.Kind() == window.Time && now.Before(ks.Span.End().Add(t.lag)) {
+			continue
+		}
+		kss = append(kss, ks)
+		delete(t.modified, ks)
+	}
+	return kss
+}
+
+// Stop the trigger.
+func (t *Closed) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.stop:
+		return
+	default:
+		close(t.stop)
+	}
+}
+
+// Discard the windows after emitting them, so that each
+// emit only holds values since the last.
+func (t *Closed) Discard() *Closed {
+	t.discard = true
+	return t
+}
+
+// Checkpoint the windows modified but not yet closed,
+// and the open ordinal windows.
+func (t *Closed) Checkpoint() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	kss := make([]KeySpan, 0, len(t.modified)+len(t.open))
+	for ks := range t.modified {
+		kss = append(kss, ks)
+	}
+	for key, s := range t.open {
+		kss = append(kss, KeySpan{Key: key, Span: s})
+	}
+	return json.Marshal(kss)
+}
+
+// Restore the windows modified but not yet closed, and
+// the open ordinal windows.
+func (t *Closed) Restore(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var kss []KeySpan
+	err := json.Unmarshal(data, &kss)
+	if err != nil {
+		return fmt.Errorf("trigger: failed to restore: %v", err)
+	}
+	t.modified = map[KeySpan]bool{}
+	t.open = map[string]window.Span{}
+	for _, ks := range kss {
+		if ks.Span.Kind() == window.Ordinal {
+			t.open[ks.Key] = ks.Span
+			continue
+		}
+		t.modified[ks] = true
+	}
+	return nil
+}
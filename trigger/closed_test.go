@@ -0,0 +1,106 @@
+package trigger
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/window"
+)
+
+func TestClosedWindows(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+
+	early := window.NewSpan(t0, t0.Add(1*time.Minute))
+	late := window.NewSpan(t0.Add(1*time.Minute), t0.Add(2*time.Minute))
+	closed := window.NewOrdinalSpan(0, 10).Close()
+	open := window.NewOrdinalSpan(1, 10)
+
+	c := WhenClosed(10 * time.Second)
+	c.Modified("key", nil, map[window.Span][]interface{}{
+		early:  nil,
+		late:   nil,
+		closed: nil,
+		open:   nil,
+	})
+
+	// Only the early window has closed, including the
+	// lag, and the closed ordinal window.
+	kss := c.closed(t0.Add(70 * time.Second))
+	found := map[window.Span]bool{}
+	for _, ks := range kss {
+		found[ks.Span] = true
+	}
+	if len(found) != 2 || !found[early] || !found[closed] {
+		t.Fatalf("expected early and closed windows, found: %v", kss)
+	}
+
+	// Closed windows are only signaled once.
+	kss = c.closed(t0.Add(3 * time.Minute))
+	if len(kss) != 1 || kss[0].Span != late {
+		t.Fatalf("expected late window, found: %v", kss)
+	}
+}
+
+func TestClosedOpenOrdinalAtEOS(t *testing.T) {
+	open := window.NewOrdinalSpan(0, 1)
+	grown := window.NewOrdinalSpan(0, 2)
+	closed := window.NewOrdinalSpan(0, 3).Close()
+
+	c := WhenClosed(10 * time.Second)
+	var signaled []KeySpan
+	c.signal = func(kss []KeySpan) error {
+		signaled = append(signaled, kss...)
+		return nil
+	}
+
+	// Only the latest open window of a key is kept,
+	// and forgotten once it closes.
+	c.Modified("key", nil, map[window.Span][]interface{}{open: nil})
+	c.Modified("key", nil, map[window.Span][]interface{}{grown: nil})
+	c.Modified("closing", nil, map[window.Span][]interface{}{open: nil})
+	c.Modified("closing", nil, map[window.Span][]interface{}{closed: nil})
+	c.closed(time.Now())
+
+	// End of stream closes the open window.
+	c.Heuristic(&progress.Heuristic{EOS: true})
+	if len(signaled) != 1 || signaled[0] != (KeySpan{Key: "key", Span: grown}) {
+		t.Fatalf("expected open window of key, found: %v", signaled)
+	}
+}
+
+func TestClosedEOSFailure(t *testing.T) {
+	c := WhenClosed(10 * time.Second)
+	fail := errors.New("failed")
+	done := make(chan error, 1)
+	go func() {
+		done <- c.StartSpans(func(kss []KeySpan) error {
+			return fail
+		})
+	}()
+	defer c.Stop()
+
+	c.Modified("key", nil, map[window.Span][]interface{}{window.NewOrdinalSpan(0, 1): nil})
+	for {
+		c.mu.Lock()
+		started := c.signal != nil
+		c.mu.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// The failure to signal end of stream stops the
+	// trigger with the failure.
+	c.Heuristic(&progress.Heuristic{EOS: true})
+	select {
+	case err := <-done:
+		if err != fail {
+			t.Fatalf("expected failure, found: %v", err)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("expected trigger to stop")
+	}
+}
@@ -0,0 +1,489 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/window"
+)
+
+type mode int
+
+const (
+	anyOf mode = iota
+	allOf
+	orFinally
+)
+
+// AfterAny fires a key once any of the triggers fire it.
+// Like the other combinators it fires the windows of each
+// key once, a key is fired again once a window it was not
+// fired with is modified. Use Repeatedly to keep firing.
+func AfterAny(ts ...Trigger) *Composite {
+	return newComposite(anyOf, ts)
+}
+
+// AfterAll fires a key once all of the triggers have
+// fired it.
+func AfterAll(ts ...Trigger) *Composite {
+	return newComposite(allOf, ts)
+}
+
+// OrFinally fires a key each time t fires it, until final
+// fires it, which fires the key a last time.
+func OrFinally(t, final Trigger) *Composite {
+	return newComposite(orFinally, []Trigger{t, final})
+}
+
+// Repeatedly fires keys each time t fires them, rather
+// than once. Triggers which are not combinators already
+// fire repeatedly, and are returned as is.
+func Repeatedly(t Trigger) Trigger {
+	if c, ok := t.(*Composite); ok {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.repeat = true
+	}
+	return t
+}
+
+func newComposite(m mode, ts []Trigger) *Composite {
+	return &Composite{
+		mode:     m,
+		ts:       ts,
+		fired:    map[string]map[int]bool{},
+		finished: map[string]map[window.Span]bool{},
+		modified: map[string]bool{},
+		windows:  map[string]map[window.Span]bool{},
+		held:     map[string][]KeySpan{},
+	}
+}
+
+// Composite trigger, which composes triggers that share
+// one signal. A key is only signaled when it has been
+// modified since it was last signaled, so a key fired by
+// several triggers at once is signaled once. The windows
+// signaled by triggers which signal windows are passed
+// on, the others signal every window of their keys.
+type Composite struct {
+	discarder
+	mu       sync.Mutex
+	mode     mode
+	ts       []Trigger
+	repeat   bool
+	fired    map[string]map[int]bool
+	finished map[string]map[window.Span]bool // Windows of finished keys.
+	modified map[string]bool
+	windows  map[string]map[window.Span]bool // Windows modified since finished.
+	held     map[string][]KeySpan            // Windows signaled of keys not all triggers fired.
+}
+
+// Heuristic is given to every trigger. Keys finished only
+// with time windows which the watermark has passed are
+// forgotten, so finished keys do not accumulate.
+func (t *Composite) Heuristic(h *progress.Heuristic) {
+	if !h.Watermark.IsZero() {
+		t.mu.Lock()
+		for key, ws := range t.finished {
+			if passed(ws, h.Watermark) {
+				delete(t.finished, key)
+			}
+		}
+		t.mu.Unlock()
+	}
+	for _, c := range t.ts {
+		c.Heuristic(h)
+	}
+}
+
+// Modified key, given to every trigger unless the key is
+// finished with every window of vs. A window it was not
+// finished with starts the key over.
+func (t *Composite) Modified(key string, v interface{}, vs map[window.Span][]interface{}) error {
+	t.mu.Lock()
+	if ws, ok := t.finished[key]; ok {
+		if finished(ws, vs) {
+			t.mu.Unlock()
+			return nil
+		}
+		delete(t.finished, key)
+		delete(t.fired, key)
+		delete(t.held, key)
+	}
+	t.modified[key] = true
+	// Only keys which can finish track their windows.
+	if !t.repeat {
+		ws, ok := t.windows[key]
+		if !ok {
+			ws = map[window.Span]bool{}
+			t.windows[key] = ws
+		}
+		for s := range vs {
+			ws[s] = true
+		}
+	}
+	t.mu.Unlock()
+
+	for _, c := range t.ts {
+		err := c.Modified(key, v, vs)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StartSpans of every trigger, and wait for them to
+// finish. If one fails the others are stopped.
+func (t *Composite) StartSpans(signal func(kss []KeySpan) error) error {
+	errs := make(chan error, len(t.ts))
+	for i, c := range t.ts {
+		go func(i int, c Trigger) {
+			errs <- startSpans(c, func(kss []KeySpan) error {
+				fire := t.fire(i, kss)
+				if len(fire) == 0 {
+					return nil
+				}
+				return signal(fire)
+			})
+		}(i, c)
+	}
+
+	var err error
+	for range t.ts {
+		e := <-errs
+		if e != nil && err == nil {
+			err = e
+			t.Stop()
+		}
+	}
+	return err
+}
+
+// Start every trigger, signalling the keys of the
+// windows they signal.
+func (t *Composite) Start(signal func(keys []string) error) error {
+	return t.StartSpans(func(kss []KeySpan) error {
+		return signal(keysOf(kss))
+	})
+}
+
+// startSpans of the trigger, a trigger which signals keys
+// signals every window of them.
+func startSpans(c Trigger, signal func(kss []KeySpan) error) error {
+	if st, ok := c.(Spanner); ok {
+		return st.StartSpans(signal)
+	}
+	return c.Start(func(keys []string) error {
+		kss := make([]KeySpan, 0, len(keys))
+		for _, key := range keys {
+			kss = append(kss, KeySpan{Key: key, Every: true})
+		}
+		return signal(kss)
+	})
+}
+
+// fire the windows signaled by the i-th trigger,
+// returning the windows to signal. Once all triggers
+// fired a key, it is signaled with the windows each
+// signaled.
+func (t *Composite) fire(i int, kss []KeySpan) []KeySpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	fired := map[string]bool{}
+	for _, key := range t.fireKeys(i, keysOf(kss)) {
+		fired[key] = true
+	}
+	var fire []KeySpan
+	for _, ks := range kss {
+		if t.mode == allOf {
+			t.held[ks.Key] = append(t.held[ks.Key], ks)
+			continue
+		}
+		if fired[ks.Key] {
+			fire = append(fire, ks)
+		}
+	}
+	if t.mode == allOf {
+		for _, key := range keysOf(kss) {
+			if fired[key] {
+				fire = append(fire, t.held[key]...)
+				delete(t.held, key)
+			}
+		}
+	}
+	return fire
+}
+
+// fireKeys signaled by the i-th trigger, returning the
+// keys to signal.
+func (t *Composite) fireKeys(i int, keys []string) []string {
+	var fire []string
+	for _, key := range keys {
+		if _, ok := t.finished[key]; ok {
+			continue
+		}
+		// The final trigger finishes keys even when
+		// there is nothing new to signal.
+		final := t.mode == orFinally && i == 1 && !t.repeat
+		if final {
+			t.finish(key)
+		}
+		if !t.modified[key] {
+			continue
+		}
+		if t.mode == allOf {
+			fired, ok := t.fired[key]
+			if !ok {
+				fired = map[int]bool{}
+				t.fired[key] = fired
+			}
+			fired[i] = true
+			if len(fired) < len(t.ts) {
+				continue
+			}
+			delete(t.fired, key)
+		}
+		if t.mode != orFinally && !t.repeat {
+			t.finish(key)
+		}
+		delete(t.modified, key)
+		fire = append(fire, key)
+	}
+	return fire
+}
+
+// finish the key with the windows modified since it
+// was last finished.
+func (t *Composite) finish(key string) {
+	ws, ok := t.windows[key]
+	if !ok {
+		ws = map[window.Span]bool{}
+	}
+	t.finished[key] = ws
+	delete(t.windows, key)
+}
+
+// finished is true when every window of vs is one of the
+// finished windows ws.
+func finished(ws map[window.Span]bool, vs map[window.Span][]interface{}) bool {
+	for s := range vs {
+		if !ws[s] {
+			return false
+		}
+	}
+	return true
+}
+
+// passed is true when the windows are all time windows
+// which end before the watermark.
+func passed(ws map[window.Span]bool, watermark time.Time) bool {
+	if len(ws) == 0 {
+		return false
+	}
+	for s := range ws {
+		if s.Kind() != window.Time || !s.End().Before(watermark) {
+			return false
+		}
+	}
+	return true
+}
+
+// Inspecting is true when any trigger is inspecting.
+func (t *Composite) Inspecting() bool {
+	for _, c := range t.ts {
+		if i, ok := c.(Inspector); ok && i.Inspecting() {
+			return true
+		}
+	}
+	return false
+}
+
+// Timing is given to every trigger which schedules keys
+// in processing time.
+func (t *Composite) Timing(ts *Timers) {
+	for _, c := range t.ts {
+		if tm, ok := c.(Timer); ok {
+			tm.Timing(ts)
+		}
+	}
+}
+
+// Forget the windows of the key in every trigger which
+// keeps state per window.
+func (t *Composite) Forget(key string, spans []window.Span) {
+	for _, c := range t.ts {
+		if f, ok := c.(Forgetter); ok {
+			f.Forget(key, spans)
+		}
+	}
+}
+
+// Merged windows of the key, given to every trigger
+// which keeps state per window.
+func (t *Composite) Merged(key string, from []window.Span, into window.Span) {
+	for _, c := range t.ts {
+		if m, ok := c.(Merger); ok {
+			m.Merged(key, from, into)
+		}
+	}
+}
+
+// Stop every trigger.
+func (t *Composite) Stop() {
+	for _, c := range t.ts {
+		c.Stop()
+	}
+}
+
+// Resetting is given to every trigger which resets
+// keys on its own.
+func (t *Composite) Resetting(reset func(keys []string) error) {
+	for _, c := range t.ts {
+		if r, ok := c.(Resetter); ok {
+			r.Resetting(reset)
+		}
+	}
+}
+
+// Discard the windows of keys after emitting them, so
+// that each emit only holds values since the last. The
+// composed triggers' own discard is ignored.
+func (t *Composite) Discard() *Composite {
+	t.discard = true
+	return t
+}
+
+// compositeKey of a checkpoint, spans cannot be keys of
+// JSON objects.
+type compositeKey struct {
+	Key      string        `json:"key"`
+	Fired    []int         `json:"fired,omitempty"`
+	Finished bool          `json:"finished,omitempty"`
+	Modified bool          `json:"modified,omitempty"`
+	Windows  []window.Span `json:"windows,omitempty"`
+}
+
+type compositeCheckpoint struct {
+	Keys     []compositeKey `json:"keys"`
+	Triggers [][]byte       `json:"triggers"`
+}
+
+// Checkpoint the state of keys, and of every trigger
+// which has state.
+func (t *Composite) Checkpoint() ([]byte, error) {
+	var c compositeCheckpoint
+	for _, ct := range t.ts {
+		var data []byte
+		if cp, ok := ct.(Checkpointer); ok {
+			var err error
+			data, err = cp.Checkpoint()
+			if err != nil {
+				return nil, err
+			}
+		}
+		c.Triggers = append(c.Triggers, data)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := map[string]*compositeKey{}
+	entry := func(key string) *compositeKey {
+		e, ok := keys[key]
+		if !ok {
+			e = &compositeKey{Key: key}
+			keys[key] = e
+		}
+		return e
+	}
+	for key, fired := range t.fired {
+		e := entry(key)
+		for i := range fired {
+			e.Fired = append(e.Fired, i)
+		}
+	}
+	for key := range t.modified {
+		entry(key).Modified = true
+	}
+	// A key is either finished with its windows, or
+	// has windows modified since.
+	for key, ws := range t.finished {
+		e := entry(key)
+		e.Finished = true
+		for s := range ws {
+			e.Windows = append(e.Windows, s)
+		}
+	}
+	for key, ws := range t.windows {
+		e := entry(key)
+		for s := range ws {
+			e.Windows = append(e.Windows, s)
+		}
+	}
+	for _, e := range keys {
+		c.Keys = append(c.Keys, *e)
+	}
+	return json.Marshal(c)
+}
+
+// Restore the state of keys, and of every trigger which
+// has state.
+func (t *Composite) Restore(data []byte) error {
+	var c compositeCheckpoint
+	err := json.Unmarshal(data, &c)
+	if err != nil {
+		return fmt.Errorf("trigger: failed to restore: %v", err)
+	}
+	if len(c.Triggers) != len(t.ts) {
+		return fmt.Errorf("trigger: failed to restore: checkpoint of %v triggers, found: %v", len(c.Triggers), len(t.ts))
+	}
+	for i, ct := range t.ts {
+		cp, ok := ct.(Checkpointer)
+		if !ok || c.Triggers[i] == nil {
+			continue
+		}
+		err := cp.Restore(c.Triggers[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.fired = map[string]map[int]bool{}
+	t.finished = map[string]map[window.Span]bool{}
+	t.modified = map[string]bool{}
+	t.windows = map[string]map[window.Span]bool{}
+	t.held = map[string][]KeySpan{}
+	for _, e := range c.Keys {
+		if len(e.Fired) > 0 {
+			fired := map[int]bool{}
+			for _, i := range e.Fired {
+				fired[i] = true
+			}
+			t.fired[e.Key] = fired
+			// The windows signaled are not kept, so
+			// the key fires with every window.
+			t.held[e.Key] = []KeySpan{{Key: e.Key, Every: true}}
+		}
+		if e.Modified {
+			t.modified[e.Key] = true
+		}
+		ws := map[window.Span]bool{}
+		for _, s := range e.Windows {
+			ws[s] = true
+		}
+		if e.Finished {
+			t.finished[e.Key] = ws
+		} else if len(ws) > 0 {
+			t.windows[e.Key] = ws
+		}
+	}
+	return nil
+}
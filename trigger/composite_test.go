@@ -0,0 +1,270 @@
+package trigger
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/window"
+)
+
+// manual trigger, which fires when told to.
+type manual struct {
+	signal func([]string) error
+	ready  chan struct{}
+	stop   chan struct{}
+}
+
+func newManual() *manual {
+	return &manual{
+		ready: make(chan struct{}),
+		stop:  make(chan struct{}),
+	}
+}
+
+func (t *manual) Heuristic(*progress.Heuristic) {}
+
+func (t *manual) Modified(key string, v interface{}, vs map[window.Span][]interface{}) error {
+	return nil
+}
+
+func (t *manual) Start(signal func(keys []string) error) error {
+	t.signal = signal
+	close(t.ready)
+	<-t.stop
+	return nil
+}
+
+func (t *manual) Stop() {
+	select {
+	case <-t.stop:
+	default:
+		close(t.stop)
+	}
+}
+
+// composite started with a signal recording fired keys.
+func started(t *Composite, ms ...*manual) *[]string {
+	var fired []string
+	go t.Start(func(keys []string) error {
+		fired = append(fired, keys...)
+		sort.Strings(fired)
+		return nil
+	})
+	for _, m := range ms {
+		<-m.ready
+	}
+	return &fired
+}
+
+func TestAfterAnyDeduplicates(t *testing.T) {
+	a, b := newManual(), newManual()
+	c := Repeatedly(AfterAny(a, b)).(*Composite)
+	fired := started(c, a, b)
+	defer c.Stop()
+
+	c.Modified("k1", nil, nil)
+	c.Modified("k2", nil, nil)
+
+	// Both fire k1, but it is only signaled once.
+	a.signal([]string{"k1"})
+	b.signal([]string{"k1", "k2"})
+	if !reflect.DeepEqual(*fired, []string{"k1", "k2"}) {
+		t.Fatalf("expected keys fired once, found: %v", *fired)
+	}
+
+	// Repeatedly fires again once modified again.
+	c.Modified("k1", nil, nil)
+	b.signal([]string{"k1"})
+	if !reflect.DeepEqual(*fired, []string{"k1", "k1", "k2"}) {
+		t.Fatalf("expected k1 fired again, found: %v", *fired)
+	}
+}
+
+func TestAfterAnyOnce(t *testing.T) {
+	a := newManual()
+	c := AfterAny(a)
+	fired := started(c, a)
+	defer c.Stop()
+
+	c.Modified("k1", nil, nil)
+	a.signal([]string{"k1"})
+	c.Modified("k1", nil, nil)
+	a.signal([]string{"k1"})
+	if !reflect.DeepEqual(*fired, []string{"k1"}) {
+		t.Fatalf("expected k1 fired once, found: %v", *fired)
+	}
+}
+
+func TestAfterAll(t *testing.T) {
+	a, b := newManual(), newManual()
+	c := AfterAll(a, b)
+	fired := started(c, a, b)
+	defer c.Stop()
+
+	c.Modified("k1", nil, nil)
+	a.signal([]string{"k1"})
+	a.signal([]string{"k1"})
+	if len(*fired) != 0 {
+		t.Fatalf("expected no keys fired, found: %v", *fired)
+	}
+	b.signal([]string{"k1"})
+	if !reflect.DeepEqual(*fired, []string{"k1"}) {
+		t.Fatalf("expected k1 fired, found: %v", *fired)
+	}
+}
+
+func TestOrFinally(t *testing.T) {
+	a, final := newManual(), newManual()
+	c := OrFinally(a, final)
+	fired := started(c, a, final)
+	defer c.Stop()
+
+	c.Modified("k1", nil, nil)
+	a.signal([]string{"k1"})
+	c.Modified("k1", nil, nil)
+	final.signal([]string{"k1"})
+
+	// After the final trigger the key is finished.
+	c.Modified("k1", nil, nil)
+	a.signal([]string{"k1"})
+	if !reflect.DeepEqual(*fired, []string{"k1", "k1"}) {
+		t.Fatalf("expected k1 fired twice, found: %v", *fired)
+	}
+}
+
+func TestAfterAnyOncePerWindow(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s0 := window.NewSpan(t0, t0.Add(1*time.Minute))
+	s1 := window.NewSpan(t0.Add(1*time.Minute), t0.Add(2*time.Minute))
+
+	a := newManual()
+	c := AfterAny(a)
+	fired := started(c, a)
+	defer c.Stop()
+
+	c.Modified("k1", nil, map[window.Span][]interface{}{s0: nil})
+	a.signal([]string{"k1"})
+	c.Modified("k1", nil, map[window.Span][]interface{}{s0: nil})
+	a.signal([]string{"k1"})
+	if !reflect.DeepEqual(*fired, []string{"k1"}) {
+		t.Fatalf("expected k1 fired once, found: %v", *fired)
+	}
+
+	// A later window of the key fires it again.
+	c.Modified("k1", nil, map[window.Span][]interface{}{s1: nil})
+	a.signal([]string{"k1"})
+	if !reflect.DeepEqual(*fired, []string{"k1", "k1"}) {
+		t.Fatalf("expected k1 fired twice, found: %v", *fired)
+	}
+
+	// Once the watermark passes its windows, the
+	// finished key is forgotten.
+	c.Heuristic(&progress.Heuristic{Watermark: t0.Add(1 * time.Minute)})
+	if _, ok := c.finished["k1"]; !ok {
+		t.Fatal("expected k1 to be finished")
+	}
+	c.Heuristic(&progress.Heuristic{Watermark: t0.Add(3 * time.Minute)})
+	if len(c.finished) != 0 {
+		t.Fatalf("expected no finished keys, found: %v", c.finished)
+	}
+}
+
+func TestCompositeCheckpoint(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s0 := window.NewSpan(t0, t0.Add(1*time.Minute))
+	vs := map[window.Span][]interface{}{s0: nil}
+
+	a, b := newManual(), newManual()
+	c := AfterAll(a, b, AtCount(10))
+	fired := started(c, a, b)
+	defer c.Stop()
+
+	c.Modified("k1", nil, vs)
+	c.Modified("k2", nil, vs)
+	a.signal([]string{"k1", "k2"})
+	b.signal([]string{"k2"})
+	if len(*fired) != 0 {
+		t.Fatalf("expected no keys fired, found: %v", *fired)
+	}
+
+	data, err := c.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := AfterAll(newManual(), newManual(), AtCount(10))
+	err = r.Restore(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(r.fired, c.fired) || !reflect.DeepEqual(r.modified, c.modified) || !reflect.DeepEqual(r.windows, c.windows) {
+		t.Fatalf("expected restored keys, found: %v %v %v", r.fired, r.modified, r.windows)
+	}
+	if n := r.ts[2].(*Count).counts["k1"][s0]; n != 1 {
+		t.Fatalf("expected restored count: 1, found: %v", n)
+	}
+
+	// A trigger checkpointed with other triggers is
+	// not restored.
+	err = AfterAll(newManual()).Restore(data)
+	if err == nil {
+		t.Fatal("expected restore to fail")
+	}
+}
+
+func TestCompositeSpans(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s0 := window.NewSpan(t0, t0.Add(1*time.Minute))
+	s1 := window.NewSpan(t0.Add(1*time.Minute), t0.Add(2*time.Minute))
+
+	// The windows a count signals pass through, a
+	// trigger of keys signals every window.
+	count, m := AtCount(2), newManual()
+	c := Repeatedly(AfterAny(count, m)).(*Composite)
+	signaled := make(chan []KeySpan, 2)
+	go c.StartSpans(func(kss []KeySpan) error {
+		signaled <- kss
+		return nil
+	})
+	defer c.Stop()
+	<-m.ready
+
+	for i := 0; i < 2; i++ {
+		c.Modified("key", i, map[window.Span][]interface{}{s0: nil})
+	}
+	c.Modified("key", 2, map[window.Span][]interface{}{s1: nil})
+	if kss := <-signaled; len(kss) != 1 || kss[0] != (KeySpan{Key: "key", Span: s0}) {
+		t.Fatalf("expected window of count, found: %v", kss)
+	}
+	c.Modified("key", 3, map[window.Span][]interface{}{s1: nil})
+	m.signal([]string{"key"})
+	if kss := <-signaled; len(kss) != 1 || kss[0] != (KeySpan{Key: "key", Every: true}) {
+		t.Fatalf("expected every window of key, found: %v", kss)
+	}
+
+	// Once all fired, the windows each signaled are
+	// signaled.
+	a, b := AtCount(1), newManual()
+	all := AfterAll(a, b)
+	go all.StartSpans(func(kss []KeySpan) error {
+		signaled <- kss
+		return nil
+	})
+	defer all.Stop()
+	<-b.ready
+
+	all.Modified("key", 0, map[window.Span][]interface{}{s0: nil})
+	time.Sleep(10 * time.Millisecond)
+	select {
+	case kss := <-signaled:
+		t.Fatalf("expected no signal, found: %v", kss)
+	default:
+	}
+	b.signal([]string{"key"})
+	kss := <-signaled
+	if len(kss) != 2 || kss[0] != (KeySpan{Key: "key", Span: s0}) || !kss[1].Every {
+		t.Fatalf("expected window of count and every window, found: %v", kss)
+	}
+}
@@ -1,51 +1,268 @@
 package trigger
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
+	"sync"
 
 	"github.com/lytics/flo/progress"
 	"github.com/lytics/flo/window"
 )
 
-// AtCount count, in per key events, emit changes.
+// AtCount count, in events per window of a key, emit
+// the window.
 func AtCount(count int) *Count {
 	return &Count{
-		count:    count,
-		modified: map[string]int{},
-		logger:   log.New(os.Stderr, "count-trigger: ", log.LstdFlags),
+		count:   count,
+		stop:    make(chan struct{}),
+		notify:  make(chan struct{}, 1),
+		counts:  map[string]map[window.Span]int{},
+		pending: map[KeySpan]bool{},
+		logger:  log.New(os.Stderr, "count-trigger: ", log.LstdFlags),
 	}
 }
 
+// Count trigger, which counts the events of each window
+// of a key. Windows which reach the count are signaled
+// by Start, after the modification of the key is done,
+// and their count starts over. Windows merged into
+// another carry their count into it. Counts of windows
+// which are deleted, closed or expired are pruned, since
+// they can no longer reach the count.
 type Count struct {
-	signal   func([]string) error
-	count    int
-	delta    bool
-	logger   *log.Logger
-	modified map[string]int
+	discarder
+	mu      sync.Mutex
+	stop    chan struct{}
+	notify  chan struct{}
+	count   int
+	delta   bool
+	logger  *log.Logger
+	counts  map[string]map[window.Span]int
+	pending map[KeySpan]bool
 }
 
-func (t *Count) Heuristic(*progress.Heuristic) {}
+// Heuristic of expiry prunes the counts of expired time
+// windows.
+func (t *Count) Heuristic(h *progress.Heuristic) {
+	if h.Expiry.IsZero() {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
 
+	for key, counts := range t.counts {
+		for s := range counts {
+			if s.Kind() == window.Time && s.End().Before(h.Expiry) {
+				delete(counts, s)
+			}
+		}
+		if len(counts) == 0 {
+			delete(t.counts, key)
+		}
+	}
+}
+
+// Forget the counts of the deleted windows of the key.
+func (t *Count) Forget(key string, spans []window.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts, ok := t.counts[key]
+	if !ok {
+		return
+	}
+	for _, s := range spans {
+		delete(counts, s)
+	}
+	if len(counts) == 0 {
+		delete(t.counts, key)
+	}
+}
+
+// Merged windows of the key, the count of the window they
+// merged into is the sum of theirs.
+func (t *Count) Merged(key string, from []window.Span, into window.Span) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts, ok := t.counts[key]
+	if !ok {
+		return
+	}
+	n := 0
+	for _, s := range from {
+		n += counts[s]
+		delete(counts, s)
+	}
+	if n > 0 {
+		counts[into] += n
+	}
+	if len(counts) == 0 {
+		delete(t.counts, key)
+	}
+}
+
+// Modified key, each window of vs holding v is counted.
 func (t *Count) Modified(key string, v interface{}, vs map[window.Span][]interface{}) error {
-	current := t.modified[key]
-	if current >= t.count {
-		t.signal([]string{key})
-		t.modified[key] = 0
-	} else {
-		t.modified[key] = current + 1
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	counts, ok := t.counts[key]
+	if !ok {
+		counts = map[window.Span]int{}
+		t.counts[key] = counts
+	}
+	for s := range vs {
+		n := counts[s] + 1
+		if n < t.count {
+			// Closed windows take no more events.
+			if s.Kind() == window.Closed {
+				delete(counts, s)
+			} else {
+				counts[s] = n
+			}
+			continue
+		}
+		delete(counts, s)
+		t.pending[KeySpan{Key: key, Span: s}] = true
+	}
+	if len(counts) == 0 {
+		delete(t.counts, key)
+	}
+	if len(t.pending) > 0 {
+		select {
+		case t.notify <- struct{}{}:
+		default:
+		}
 	}
 	return nil
 }
 
+// StartSpans of the trigger, signalling the windows which
+// reached the count.
+func (t *Count) StartSpans(signal func(kss []KeySpan) error) error {
+	for {
+		select {
+		case <-t.stop:
+			return nil
+		case <-t.notify:
+			kss := t.take()
+			if len(kss) == 0 {
+				continue
+			}
+			err := signal(kss)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Start the trigger, signalling the keys of windows which
+// reached the count.
 func (t *Count) Start(signal func(keys []string) error) error {
-	t.signal = signal
-	return nil
+	return t.StartSpans(func(kss []KeySpan) error {
+		return signal(keysOf(kss))
+	})
 }
 
-func (t *Count) Stop() {}
+func (t *Count) take() []KeySpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	kss := make([]KeySpan, 0, len(t.pending))
+	for ks := range t.pending {
+		kss = append(kss, ks)
+	}
+	t.pending = map[KeySpan]bool{}
+	return kss
+}
+
+// Stop the trigger.
+func (t *Count) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.stop:
+		return
+	default:
+		close(t.stop)
+	}
+}
 
 func (t *Count) Delta() *Count {
 	t.delta = true
 	return t
 }
+
+// countEntry of a checkpoint, spans cannot be keys
+// of JSON objects.
+type countEntry struct {
+	Key     string      `json:"key"`
+	Span    window.Span `json:"span"`
+	N       int         `json:"n"`
+	Pending bool        `json:"pending,omitempty"`
+}
+
+// Checkpoint the counts of windows, and the windows
+// not yet signaled.
+func (t *Count) Checkpoint() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var es []countEntry
+	for key, counts := range t.counts {
+		for s, n := range counts {
+			es = append(es, countEntry{Key: key, Span: s, N: n})
+		}
+	}
+	for ks := range t.pending {
+		es = append(es, countEntry{Key: ks.Key, Span: ks.Span, Pending: true})
+	}
+	return json.Marshal(es)
+}
+
+// Restore the counts of windows, and the windows not
+// yet signaled.
+func (t *Count) Restore(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var es []countEntry
+	err := json.Unmarshal(data, &es)
+	if err != nil {
+		return fmt.Errorf("trigger: failed to restore: %v", err)
+	}
+	t.counts = map[string]map[window.Span]int{}
+	t.pending = map[KeySpan]bool{}
+	for _, e := range es {
+		if e.Pending {
+			t.pending[KeySpan{Key: e.Key, Span: e.Span}] = true
+			continue
+		}
+		counts, ok := t.counts[e.Key]
+		if !ok {
+			counts = map[window.Span]int{}
+			t.counts[e.Key] = counts
+		}
+		counts[e.Span] = e.N
+	}
+	if len(t.pending) > 0 {
+		select {
+		case t.notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Discard the windows of keys after emitting them, so
+// that each emit only holds values since the last.
+func (t *Count) Discard() *Count {
+	t.discard = true
+	return t
+}
@@ -0,0 +1,143 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/window"
+)
+
+func TestCountPerWindow(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s0 := window.NewSpan(t0, t0.Add(1*time.Minute))
+	s1 := window.NewSpan(t0.Add(1*time.Minute), t0.Add(2*time.Minute))
+
+	c := AtCount(3)
+	for i := 0; i < 3; i++ {
+		c.Modified("key", i, map[window.Span][]interface{}{s0: nil})
+		if i < 2 {
+			c.Modified("key", i, map[window.Span][]interface{}{s1: nil})
+		}
+	}
+
+	// Only the window with three events fires, on the
+	// third event, not the fourth.
+	kss := c.take()
+	if len(kss) != 1 || kss[0] != (KeySpan{Key: "key", Span: s0}) {
+		t.Fatalf("expected first window, found: %v", kss)
+	}
+	if n := c.counts["key"][s1]; n != 2 {
+		t.Fatalf("expected second window count: 2, found: %v", n)
+	}
+}
+
+func TestCountMergedWindows(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s0 := window.NewSpan(t0, t0.Add(1*time.Minute))
+	s1 := window.NewSpan(t0.Add(2*time.Minute), t0.Add(3*time.Minute))
+	merged := s0.Expand(s1)
+
+	c := AtCount(3)
+	c.Modified("key", 0, map[window.Span][]interface{}{s0: nil})
+	c.Modified("key", 1, map[window.Span][]interface{}{s1: nil})
+
+	// The merged session carries the counts of both.
+	c.Merged("key", []window.Span{s0, s1}, merged)
+	c.Modified("key", 2, map[window.Span][]interface{}{merged: nil})
+	kss := c.take()
+	if len(kss) != 1 || kss[0].Span != merged {
+		t.Fatalf("expected merged window, found: %v", kss)
+	}
+}
+
+func TestCountNestedWindows(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	w := window.Assign(func(ts time.Time, v interface{}) []window.Span {
+		hour := ts.Truncate(time.Hour)
+		day := ts.Truncate(24 * time.Hour)
+		return []window.Span{window.NewSpan(hour, hour.Add(time.Hour)), window.NewSpan(day, day.Add(24*time.Hour))}
+	})
+	hour, day := w.Apply(t0, nil)[0], w.Apply(t0, nil)[1]
+
+	// Nested windows are counted apart, the hour is
+	// not merged into the day which contains it.
+	c := AtCount(3)
+	c.Modified("key", 0, map[window.Span][]interface{}{hour: nil})
+	c.Modified("key", 0, map[window.Span][]interface{}{day: nil})
+	c.Modified("key", 1, map[window.Span][]interface{}{hour: nil})
+	c.Modified("key", 1, map[window.Span][]interface{}{day: nil})
+	if c.counts["key"][hour] != 2 || c.counts["key"][day] != 2 {
+		t.Fatalf("expected counts of both windows, found: %v", c.counts["key"])
+	}
+	if kss := c.take(); len(kss) != 0 {
+		t.Fatalf("expected no signal, found: %v", kss)
+	}
+
+	c.Modified("key", 2, map[window.Span][]interface{}{hour: nil})
+	c.Modified("key", 2, map[window.Span][]interface{}{day: nil})
+	if kss := c.take(); len(kss) != 2 {
+		t.Fatalf("expected both windows signaled on their third event, found: %v", kss)
+	}
+}
+
+func TestCountCheckpoint(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s0 := window.NewSpan(t0, t0.Add(1*time.Minute))
+
+	c := AtCount(3)
+	c.Modified("key", 0, map[window.Span][]interface{}{s0: nil})
+	data, err := c.Checkpoint()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := AtCount(3)
+	err = r.Restore(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := r.counts["key"][s0]; n != 1 {
+		t.Fatalf("expected count: 1, found: %v", n)
+	}
+}
+
+func TestCountPrune(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s0 := window.NewSpan(t0, t0.Add(1*time.Minute))
+	s1 := window.NewSpan(t0.Add(1*time.Minute), t0.Add(2*time.Minute))
+	o0 := window.NewOrdinalSpan(0, 1)
+
+	c := AtCount(3)
+	c.Modified("key", 0, map[window.Span][]interface{}{s0: nil, o0: nil})
+	c.Modified("key", 1, map[window.Span][]interface{}{s1: nil})
+	c.Modified("other", 0, map[window.Span][]interface{}{s0: nil})
+
+	// Deleted windows are forgotten.
+	c.Forget("other", []window.Span{s0})
+	if _, ok := c.counts["other"]; ok {
+		t.Fatalf("expected other key to be forgotten, found: %v", c.counts["other"])
+	}
+
+	// Expired time windows are pruned.
+	c.Heuristic(&progress.Heuristic{Expiry: t0.Add(90 * time.Second)})
+	if _, ok := c.counts["key"][s0]; ok || c.counts["key"][s1] != 1 {
+		t.Fatalf("expected only the expired window to be pruned, found: %v", c.counts["key"])
+	}
+
+	// A closed window takes no more events, so its
+	// count, carried over from the open window, is
+	// pruned.
+	o1 := window.NewOrdinalSpan(0, 2).Close()
+	c.Merged("key", []window.Span{o0}, o1)
+	c.Modified("key", 1, map[window.Span][]interface{}{o1: nil})
+	if _, ok := c.counts["key"][o0]; ok {
+		t.Fatalf("expected open window to be pruned, found: %v", c.counts["key"])
+	}
+	if _, ok := c.counts["key"][o1]; ok {
+		t.Fatalf("expected closed window to be pruned, found: %v", c.counts["key"])
+	}
+	if kss := c.take(); len(kss) != 0 {
+		t.Fatalf("expected no signal, found: %v", kss)
+	}
+}
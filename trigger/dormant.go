@@ -1,6 +1,8 @@
 package trigger
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"sync"
@@ -15,6 +17,7 @@ func WhenDormant(after time.Duration) *Dormant {
 	return &Dormant{
 		stop:     make(chan struct{}),
 		after:    after,
+		clock:    NewTimers().Clock(),
 		modified: map[string]time.Time{},
 		logger:   log.New(os.Stderr, "dormant-trigger: ", log.LstdFlags),
 	}
@@ -22,12 +25,15 @@ func WhenDormant(after time.Duration) *Dormant {
 
 // Dormant data trigger.
 type Dormant struct {
+	discarder
 	mu       sync.Mutex
 	stop     chan struct{}
 	after    time.Duration
+	jitter   time.Duration
 	delta    bool
 	logger   *log.Logger
-	ticker   *time.Ticker
+	clock    *Clock
+	shared   bool // Timers run by the process.
 	modified map[string]time.Time
 }
 
@@ -38,44 +44,48 @@ func (t *Dormant) Modified(key string, v interface{}, vs map[window.Span][]inter
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	t.modified[key] = time.Now()
+	now := time.Now()
+	t.modified[key] = now
+	t.schedule(key, now)
 	return nil
 }
 
+// schedule the key to fire once dormant since ts.
+func (t *Dormant) schedule(key string, ts time.Time) {
+	t.clock.Schedule(key, ts.Add(t.after).Add(spread(t.jitter)))
+}
+
 // Start the trigger, signalling changed keys with the signal function.
 func (t *Dormant) Start(signal func(keys []string) error) error {
-	freq := t.after / 100
-	if freq < 1*time.Second {
-		freq = 100 * time.Millisecond
-	}
-	t.ticker = time.NewTicker(freq)
+	t.mu.Lock()
+	clock, shared := t.clock, t.shared
+	t.mu.Unlock()
 
-	snapshot := func(now time.Time) []string {
+	if !shared {
+		go clock.Timers().Run(t.stop)
+	}
+	return clock.Run(t.stop, func(keys []string) error {
 		t.mu.Lock()
-		defer t.mu.Unlock()
-
-		stale := []string{}
-		for key, ts := range t.modified {
-			if now.Sub(ts) > t.after {
-				stale = append(stale, key)
+		for _, key := range keys {
+			// Unless modified again since it fired.
+			if !t.clock.Scheduled(key) {
 				delete(t.modified, key)
 			}
 		}
+		t.mu.Unlock()
 
-		return stale
-	}
+		return signal(keys)
+	})
+}
 
-	for {
-		select {
-		case <-t.stop:
-			return nil
-		case now := <-t.ticker.C:
-			err := signal(snapshot(now))
-			if err != nil {
-				return err
-			}
-		}
-	}
+// Timing keys on the timers of the process, rather
+// than on timers of its own.
+func (t *Dormant) Timing(ts *Timers) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.clock = ts.Clock()
+	t.shared = true
 }
 
 // Stop the trigger.
@@ -91,8 +101,50 @@ func (t *Dormant) Stop() {
 	}
 }
 
+// Jitter the time keys are emitted by up to d, to spread
+// the load of emits on sinks.
+func (t *Dormant) Jitter(d time.Duration) *Dormant {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.jitter = d
+	return t
+}
+
 // Delta of current and previous value should be emitted.
 func (t *Dormant) Delta() *Dormant {
 	t.delta = true
 	return t
 }
+
+// Checkpoint the modified keys.
+func (t *Dormant) Checkpoint() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return json.Marshal(t.modified)
+}
+
+// Restore the modified keys.
+func (t *Dormant) Restore(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	modified := map[string]time.Time{}
+	err := json.Unmarshal(data, &modified)
+	if err != nil {
+		return fmt.Errorf("trigger: failed to restore: %v", err)
+	}
+	t.modified = modified
+	for key, ts := range modified {
+		t.schedule(key, ts)
+	}
+	return nil
+}
+
+// Discard the windows of keys after emitting them, so
+// that each emit only holds values since the last.
+func (t *Dormant) Discard() *Dormant {
+	t.discard = true
+	return t
+}
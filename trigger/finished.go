@@ -1,6 +1,8 @@
 package trigger
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"sync"
@@ -18,32 +20,50 @@ func WhenFinished() *Finished {
 }
 
 type Finished struct {
+	discarder
 	mu       sync.Mutex
 	stop     chan struct{}
+	eos      bool
 	logger   *log.Logger
 	signal   func([]string) error
 	modified map[string]bool
 }
 
+// Heuristic of end of stream signals every modified key.
+// When the trigger is not yet started, the keys are
+// signaled once it is.
 func (t *Finished) Heuristic(h *progress.Heuristic) {
 	if !h.EOS {
 		return
 	}
 
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	t.eos = true
+	t.mu.Unlock()
+
+	t.finish()
+}
 
-	if len(t.modified) == 0 {
+// finish the stream, signalling every modified key, once
+// both the end of stream and the signal are known.
+func (t *Finished) finish() {
+	t.mu.Lock()
+	if !t.eos || t.signal == nil || len(t.modified) == 0 {
+		t.mu.Unlock()
 		return
 	}
-
 	keys := []string{}
 	for key := range t.modified {
 		keys = append(keys, key)
 	}
 	t.modified = map[string]bool{}
+	signal := t.signal
+	t.mu.Unlock()
 
-	t.signal(keys)
+	err := signal(keys)
+	if err != nil {
+		t.logger.Printf("failed signalling finished keys: %v", err)
+	}
 }
 
 func (t *Finished) Modified(key string, v interface{}, vs map[window.Span][]interface{}) error {
@@ -55,7 +75,11 @@ func (t *Finished) Modified(key string, v interface{}, vs map[window.Span][]inte
 }
 
 func (t *Finished) Start(signal func(keys []string) error) error {
+	t.mu.Lock()
 	t.signal = signal
+	t.mu.Unlock()
+
+	t.finish()
 	<-t.stop
 	return nil
 }
@@ -72,3 +96,32 @@ func (t *Finished) Stop() {
 		close(t.stop)
 	}
 }
+
+// Checkpoint the modified keys.
+func (t *Finished) Checkpoint() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return json.Marshal(t.modified)
+}
+
+// Restore the modified keys.
+func (t *Finished) Restore(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	modified := map[string]bool{}
+	err := json.Unmarshal(data, &modified)
+	if err != nil {
+		return fmt.Errorf("trigger: failed to restore: %v", err)
+	}
+	t.modified = modified
+	return nil
+}
+
+// Discard the windows of keys after emitting them, so
+// that each emit only holds values since the last.
+func (t *Finished) Discard() *Finished {
+	t.discard = true
+	return t
+}
@@ -0,0 +1,34 @@
+package trigger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/progress"
+)
+
+func TestFinishedBeforeStart(t *testing.T) {
+	f := WhenFinished()
+	f.Modified("key", nil, nil)
+
+	// End of stream before the trigger is started is
+	// signaled once it is.
+	f.Heuristic(&progress.Heuristic{EOS: true})
+
+	signaled := make(chan []string, 1)
+	go f.Start(func(keys []string) error {
+		signaled <- keys
+		return nil
+	})
+	defer f.Stop()
+
+	select {
+	case keys := <-signaled:
+		if !reflect.DeepEqual(keys, []string{"key"}) {
+			t.Fatalf("expected key signaled, found: %v", keys)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("expected key signaled")
+	}
+}
@@ -1,6 +1,8 @@
 package trigger
 
 import (
+	"encoding/json"
+	"fmt"
 	"log"
 	"os"
 	"sync"
@@ -10,22 +12,32 @@ import (
 	"github.com/lytics/flo/window"
 )
 
-// AtPeriod period, in processing time, emit changes.
+// AtPeriod period, in processing time, emit changes. A
+// modified key is emitted at the end of the period it
+// was modified in, periods start when the trigger is
+// created unless aligned.
 func AtPeriod(period time.Duration) *Period {
 	return &Period{
-		stop:     make(chan bool),
+		stop:     make(chan struct{}),
 		delta:    false,
 		period:   period,
+		origin:   time.Now(),
+		clock:    NewTimers().Clock(),
 		modified: map[string]bool{},
 		logger:   log.New(os.Stderr, "period-trigger: ", log.LstdFlags),
 	}
 }
 
 type Period struct {
+	discarder
 	mu       sync.Mutex
-	stop     chan bool
+	stop     chan struct{}
 	delta    bool
 	period   time.Duration
+	origin   time.Time
+	jitter   time.Duration
+	clock    *Clock
+	shared   bool // Timers run by the process.
 	logger   *log.Logger
 	modified map[string]bool
 }
@@ -36,38 +48,48 @@ func (t *Period) Modified(key string, v interface{}, vs map[window.Span][]interf
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	t.modified[key] = true
+	if !t.modified[key] {
+		t.modified[key] = true
+		t.schedule(key, time.Now())
+	}
 	return nil
 }
 
+// schedule the key at the end of the period of now.
+func (t *Period) schedule(key string, now time.Time) {
+	t.clock.Schedule(key, aligned(now, t.origin, t.period).Add(spread(t.jitter)))
+}
+
 func (t *Period) Start(signal func(keys []string) error) error {
-	ticker := time.NewTicker(t.period)
-	defer ticker.Stop()
+	t.mu.Lock()
+	clock, shared := t.clock, t.shared
+	t.mu.Unlock()
 
-	snapshot := func() []string {
+	if !shared {
+		go clock.Timers().Run(t.stop)
+	}
+	return clock.Run(t.stop, func(keys []string) error {
 		t.mu.Lock()
-		defer t.mu.Unlock()
-
-		keys := []string{}
-		for key := range t.modified {
-			keys = append(keys, key)
+		for _, key := range keys {
+			// Unless modified again since it fired.
+			if !t.clock.Scheduled(key) {
+				delete(t.modified, key)
+			}
 		}
-		t.modified = map[string]bool{}
+		t.mu.Unlock()
 
-		return keys
-	}
+		return signal(keys)
+	})
+}
 
-	for {
-		select {
-		case <-t.stop:
-			return nil
-		case <-ticker.C:
-			err := signal(snapshot())
-			if err != nil {
-				return err
-			}
-		}
-	}
+// Timing keys on the timers of the process, rather
+// than on timers of its own.
+func (t *Period) Timing(ts *Timers) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.clock = ts.Clock()
+	t.shared = true
 }
 
 func (t *Period) Stop() {
@@ -82,7 +104,61 @@ func (t *Period) Stop() {
 	}
 }
 
+// Align periods to wall-clock boundaries, multiples of the
+// period since the Unix epoch, for example the top of every
+// minute for a period of a minute.
+func (t *Period) Align() *Period {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.origin = epoch
+	return t
+}
+
+// Jitter the end of the period by up to d for each key,
+// to spread the load of emits on sinks.
+func (t *Period) Jitter(d time.Duration) *Period {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.jitter = d
+	return t
+}
+
 func (t *Period) Delta() *Period {
 	t.delta = true
 	return t
 }
+
+// Checkpoint the modified keys.
+func (t *Period) Checkpoint() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return json.Marshal(t.modified)
+}
+
+// Restore the modified keys.
+func (t *Period) Restore(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	modified := map[string]bool{}
+	err := json.Unmarshal(data, &modified)
+	if err != nil {
+		return fmt.Errorf("trigger: failed to restore: %v", err)
+	}
+	t.modified = modified
+	now := time.Now()
+	for key := range modified {
+		t.schedule(key, now)
+	}
+	return nil
+}
+
+// Discard the windows of keys after emitting them, so
+// that each emit only holds values since the last.
+func (t *Period) Discard() *Period {
+	t.discard = true
+	return t
+}
@@ -0,0 +1,135 @@
+package trigger
+
+import (
+	"log"
+	"os"
+	"sync"
+
+	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/window"
+)
+
+// ResetKeys resets the windows of keys, explicitly by
+// Reset, or by a value for which the predicate of When
+// is true. It never emits, so it is composed with the
+// triggers which do, for example:
+//
+//	Repeatedly(AfterAny(AtCount(10), ResetKeys().When(isMarker)))
+//
+// Keys are also reset from outside the graph by the
+// server's ResetKeys.
+func ResetKeys() *Resets {
+	return &Resets{
+		stop:    make(chan struct{}),
+		notify:  make(chan struct{}, 1),
+		pending: map[string]bool{},
+		logger:  log.New(os.Stderr, "reset-trigger: ", log.LstdFlags),
+	}
+}
+
+// Resets trigger. Keys are reset shortly after being
+// requested, not while they are modified, so values
+// merged in between are reset as well.
+type Resets struct {
+	mu      sync.Mutex
+	f       func(key string, v interface{}) bool
+	stop    chan struct{}
+	notify  chan struct{}
+	reset   func(keys []string) error
+	logger  *log.Logger
+	pending map[string]bool
+}
+
+// When the predicate f returns true for a value modifying
+// a key, the key is reset.
+func (t *Resets) When(f func(key string, v interface{}) bool) *Resets {
+	t.f = f
+	return t
+}
+
+// Reset the keys.
+func (t *Resets) Reset(keys ...string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for _, key := range keys {
+		t.pending[key] = true
+	}
+	t.wake()
+}
+
+func (t *Resets) Heuristic(*progress.Heuristic) {}
+
+// Modified key, which is reset when the predicate is true
+// for v.
+func (t *Resets) Modified(key string, v interface{}, vs map[window.Span][]interface{}) error {
+	if t.f == nil || !t.f(key, v) {
+		return nil
+	}
+	t.Reset(key)
+	return nil
+}
+
+// Resetting keys with reset.
+func (t *Resets) Resetting(reset func(keys []string) error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.reset = reset
+}
+
+// Start the trigger, which resets keys rather than
+// signalling them.
+func (t *Resets) Start(signal func(keys []string) error) error {
+	for {
+		select {
+		case <-t.stop:
+			return nil
+		case <-t.notify:
+			keys, reset := t.take()
+			if len(keys) == 0 {
+				continue
+			}
+			if reset == nil {
+				t.logger.Printf("dropping reset of %v keys, no reset was given", len(keys))
+				continue
+			}
+			err := reset(keys)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (t *Resets) take() ([]string, func(keys []string) error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	keys := make([]string, 0, len(t.pending))
+	for key := range t.pending {
+		keys = append(keys, key)
+	}
+	t.pending = map[string]bool{}
+	return keys, t.reset
+}
+
+// Stop the trigger.
+func (t *Resets) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.stop:
+		return
+	default:
+		close(t.stop)
+	}
+}
+
+func (t *Resets) wake() {
+	select {
+	case t.notify <- struct{}{}:
+	default:
+	}
+}
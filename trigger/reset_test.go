@@ -0,0 +1,49 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/window"
+)
+
+func TestResetKeys(t *testing.T) {
+	marker := func(key string, v interface{}) bool {
+		return v == "reset"
+	}
+	r := ResetKeys().When(marker)
+
+	reset := make(chan []string, 1)
+	r.Resetting(func(keys []string) error {
+		reset <- keys
+		return nil
+	})
+	go r.Start(func(keys []string) error {
+		t.Errorf("expected no signal, found: %v", keys)
+		return nil
+	})
+	defer r.Stop()
+
+	s := window.NewSpan(time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC), time.Date(2017, 01, 01, 14, 0, 0, 0, time.UTC))
+	r.Modified("a", 1, map[window.Span][]interface{}{s: {1}})
+	r.Modified("a", "reset", map[window.Span][]interface{}{s: {1, "reset"}})
+	select {
+	case keys := <-reset:
+		if len(keys) != 1 || keys[0] != "a" {
+			t.Fatalf("expected key a reset, found: %v", keys)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("expected reset")
+	}
+
+	// Explicitly.
+	r.Reset("b")
+	select {
+	case keys := <-reset:
+		if len(keys) != 1 || keys[0] != "b" {
+			t.Fatalf("expected key b reset, found: %v", keys)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("expected reset")
+	}
+}
@@ -0,0 +1,279 @@
+package trigger
+
+import (
+	"container/heap"
+	"math/rand"
+	"sync"
+	"time"
+)
+
+// NewTimers for scheduling keys in processing time.
+func NewTimers() *Timers {
+	return &Timers{
+		wake:    make(chan struct{}, 1),
+		pending: map[*Clock]bool{},
+	}
+}
+
+// Timers of keys, kept in one priority queue by time, so
+// scheduling a key is O(log n) and only the earliest
+// timer is waited on, rather than polling every key. The
+// timers are shared by the triggers of a process, each
+// schedules its keys on its own clock.
+type Timers struct {
+	mu      sync.Mutex
+	queue   timerQueue
+	wake    chan struct{}
+	pending map[*Clock]bool // Clocks with due keys.
+}
+
+// Clock of one trigger, scheduling its keys on the timers.
+func (ts *Timers) Clock() *Clock {
+	return &Clock{
+		timers: ts,
+		index:  map[string]*timer{},
+		errc:   make(chan error, 1),
+	}
+}
+
+// Run the timers until stop is closed, giving the keys
+// whose time has come to the clock which scheduled them.
+func (ts *Timers) Run(stop <-chan struct{}) {
+	wait := time.NewTimer(0)
+	defer wait.Stop()
+
+	for {
+		now := time.Now()
+		ts.due(now)
+		for c, keys := range ts.take() {
+			c.fired(keys)
+		}
+		if !wait.Stop() {
+			select {
+			case <-wait.C:
+			default:
+			}
+		}
+		wait.Reset(ts.next(now))
+
+		select {
+		case <-stop:
+			return
+		case <-ts.wake:
+		case <-wait.C:
+		}
+	}
+}
+
+// due timers at the time now, which are removed and left
+// with their clock until it runs.
+func (ts *Timers) due(now time.Time) {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	for len(ts.queue) > 0 && !ts.queue[0].at.After(now) {
+		t := heap.Pop(&ts.queue).(*timer)
+		delete(t.clock.index, t.key)
+		t.clock.due = append(t.clock.due, t.key)
+		ts.pending[t.clock] = true
+	}
+}
+
+// take the due keys of every running clock.
+func (ts *Timers) take() map[*Clock][]string {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	var taken map[*Clock][]string
+	for c := range ts.pending {
+		if c.fire == nil {
+			continue
+		}
+		if taken == nil {
+			taken = map[*Clock][]string{}
+		}
+		taken[c] = c.due
+		c.due = nil
+		delete(ts.pending, c)
+	}
+	return taken
+}
+
+// next duration to wait for the earliest timer.
+func (ts *Timers) next(now time.Time) time.Duration {
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if len(ts.queue) == 0 {
+		// Nothing to wait for, until woken
+		// by a schedule.
+		return 1 * time.Hour
+	}
+	d := ts.queue[0].at.Sub(now)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+func (ts *Timers) poke() {
+	select {
+	case ts.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Clock of a trigger, its keys are scheduled on shared
+// timers.
+type Clock struct {
+	timers *Timers
+	index  map[string]*timer
+	due    []string
+	fire   func(keys []string) error
+	errc   chan error
+}
+
+// Timers the clock schedules on.
+func (c *Clock) Timers() *Timers {
+	return c.timers
+}
+
+// Schedule the key to fire at the given time, replacing
+// any time it was scheduled at before.
+func (c *Clock) Schedule(key string, at time.Time) {
+	ts := c.timers
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	if t, ok := c.index[key]; ok {
+		t.at = at
+		heap.Fix(&ts.queue, t.i)
+	} else {
+		t := &timer{clock: c, key: key, at: at}
+		c.index[key] = t
+		heap.Push(&ts.queue, t)
+	}
+	ts.poke()
+}
+
+// Scheduled is true when the key has a timer.
+func (c *Clock) Scheduled(key string) bool {
+	c.timers.mu.Lock()
+	defer c.timers.mu.Unlock()
+
+	_, ok := c.index[key]
+	return ok
+}
+
+// Cancel the timer of the key, if any.
+func (c *Clock) Cancel(key string) {
+	ts := c.timers
+	ts.mu.Lock()
+	defer ts.mu.Unlock()
+
+	t, ok := c.index[key]
+	if !ok {
+		return
+	}
+	heap.Remove(&ts.queue, t.i)
+	delete(c.index, key)
+}
+
+// Run the clock until stop is closed, calling fire with
+// the keys whose time has come. Each timer fires once.
+// The timers must be running, fire is called by them.
+func (c *Clock) Run(stop <-chan struct{}, fire func(keys []string) error) error {
+	ts := c.timers
+	ts.mu.Lock()
+	c.fire = fire
+	ts.mu.Unlock()
+	ts.poke()
+
+	defer func() {
+		ts.mu.Lock()
+		c.fire = nil
+		ts.mu.Unlock()
+	}()
+
+	select {
+	case <-stop:
+		return nil
+	case err := <-c.errc:
+		return err
+	}
+}
+
+// fired keys, a failure of fire stops the run of the
+// clock.
+func (c *Clock) fired(keys []string) {
+	c.timers.mu.Lock()
+	fire := c.fire
+	c.timers.mu.Unlock()
+	if fire == nil {
+		return
+	}
+	err := fire(keys)
+	if err != nil {
+		select {
+		case c.errc <- err:
+		default:
+		}
+	}
+}
+
+type timer struct {
+	clock *Clock
+	key   string
+	at    time.Time
+	i     int
+}
+
+// timerQueue implements heap.Interface, ordered by
+// time, earliest first.
+type timerQueue []*timer
+
+func (q timerQueue) Len() int { return len(q) }
+
+func (q timerQueue) Less(i, j int) bool { return q[i].at.Before(q[j].at) }
+
+func (q timerQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].i = i
+	q[j].i = j
+}
+
+func (q *timerQueue) Push(x interface{}) {
+	t := x.(*timer)
+	t.i = len(*q)
+	*q = append(*q, t)
+}
+
+func (q *timerQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	t := old[n-1]
+	*q = old[:n-1]
+	return t
+}
+
+// aligned time after now, at a whole number of periods
+// since origin.
+func aligned(now, origin time.Time, period time.Duration) time.Time {
+	if period <= 0 {
+		return now
+	}
+	n := now.Sub(origin) / period
+	return origin.Add((n + 1) * period)
+}
+
+// epoch origin of aligned periods, so they land on
+// wall-clock boundaries.
+var epoch = time.Unix(0, 0)
+
+// spread of a random duration up to jitter.
+func spread(jitter time.Duration) time.Duration {
+	if jitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(jitter)))
+}
@@ -0,0 +1,91 @@
+package trigger
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTimersDue(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+
+	ts := NewTimers()
+	c := ts.Clock()
+	c.Schedule("a", t0.Add(2*time.Second))
+	c.Schedule("b", t0.Add(1*time.Second))
+	c.Schedule("c", t0.Add(3*time.Second))
+
+	// Rescheduling replaces the earlier time.
+	c.Schedule("c", t0)
+	c.Cancel("b")
+
+	ts.due(t0.Add(2 * time.Second))
+	if !reflect.DeepEqual(c.due, []string{"c", "a"}) {
+		t.Fatalf("expected keys in time order, found: %v", c.due)
+	}
+	if c.Scheduled("a") || c.Scheduled("b") || c.Scheduled("c") {
+		t.Fatal("expected no timers left")
+	}
+}
+
+func TestTimersShared(t *testing.T) {
+	ts := NewTimers()
+	stop := make(chan struct{})
+	defer close(stop)
+	go ts.Run(stop)
+
+	// Each clock fires its own keys, even when the
+	// keys are the same.
+	fired := make(chan string, 2)
+	for _, name := range []string{"first", "second"} {
+		name := name
+		c := ts.Clock()
+		go c.Run(stop, func(keys []string) error {
+			if !reflect.DeepEqual(keys, []string{"a"}) {
+				t.Errorf("expected key a, found: %v", keys)
+			}
+			fired <- name
+			return nil
+		})
+		c.Schedule("a", time.Now().Add(10*time.Millisecond))
+	}
+
+	seen := map[string]bool{}
+	for len(seen) < 2 {
+		select {
+		case name := <-fired:
+			seen[name] = true
+		case <-time.After(1 * time.Second):
+			t.Fatalf("expected both clocks to fire, found: %v", seen)
+		}
+	}
+}
+
+func TestClockRunFails(t *testing.T) {
+	ts := NewTimers()
+	stop := make(chan struct{})
+	defer close(stop)
+	go ts.Run(stop)
+
+	// Keys due before the clock runs are kept for it.
+	c := ts.Clock()
+	c.Schedule("a", time.Now())
+	time.Sleep(10 * time.Millisecond)
+
+	fail := errors.New("failed")
+	err := c.Run(stop, func(keys []string) error {
+		return fail
+	})
+	if err != fail {
+		t.Fatalf("expected failure of fire, found: %v", err)
+	}
+}
+
+func TestAligned(t *testing.T) {
+	now := time.Date(2017, 01, 01, 13, 47, 12, 0, time.UTC)
+	next := aligned(now, epoch, time.Minute)
+	if !next.Equal(time.Date(2017, 01, 01, 13, 48, 0, 0, time.UTC)) {
+		t.Fatalf("expected top of the next minute, found: %v", next)
+	}
+}
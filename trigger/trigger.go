@@ -2,6 +2,7 @@ package trigger
 
 import (
 	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/sink"
 	"github.com/lytics/flo/window"
 )
 
@@ -12,3 +13,127 @@ type Trigger interface {
 	Start(func(keys []string) error) error
 	Stop()
 }
+
+// Discarder is implemented by triggers which can discard
+// the windows of keys after emitting them, rather than
+// accumulating values across emits.
+type Discarder interface {
+	Discarding() bool
+}
+
+// discarder is embedded by triggers which can discard,
+// their Discard sets discard.
+type discarder struct {
+	discard bool
+}
+
+// Discarding is true when windows are discarded after
+// being emitted.
+func (d *discarder) Discarding() bool {
+	return d.discard
+}
+
+// Resetter is implemented by triggers which reset keys
+// on their own. Before the trigger is started it is
+// given the function to reset keys with.
+type Resetter interface {
+	Resetting(reset func(keys []string) error)
+}
+
+// Timer is implemented by triggers which schedule keys in
+// processing time. Before the trigger is restored or
+// started it is given the timers of its process, which
+// are shared by every trigger of the process, and run by
+// the process. Otherwise the trigger runs its own.
+type Timer interface {
+	Timing(ts *Timers)
+}
+
+// Inspector is implemented by triggers which inspect the
+// values of windows. Windows assembled from the stored
+// windows of a key, such as sliding windows from panes,
+// are only assembled per modification for triggers which
+// are inspecting. Others are given the values of the
+// stored window modified.
+type Inspector interface {
+	Inspecting() bool
+}
+
+// Forgetter is implemented by triggers which keep state
+// per window. Forget is called with the windows of a key
+// which were deleted, such as by a discarding fire or a
+// reset, while the key is held.
+type Forgetter interface {
+	Forget(key string, spans []window.Span)
+}
+
+// Merger is implemented by triggers which keep state per
+// window. Merged is called with the windows of a key the
+// window merged into another, such as sessions, or an
+// ordinal window growing, before the modification is
+// given to the trigger, while the key is held.
+type Merger interface {
+	Merged(key string, from []window.Span, into window.Span)
+}
+
+// Checkpointer is implemented by triggers whose state is
+// checkpointed with the windows of the graph, so keys
+// modified but not yet fired survive a restart. Restore
+// is called before the trigger is started.
+type Checkpointer interface {
+	Checkpoint() ([]byte, error)
+	Restore(data []byte) error
+}
+
+// KeySpan names one window of a key, or every window of
+// the key when Every is true, such as for a trigger which
+// signals keys composed with one which signals windows.
+type KeySpan struct {
+	Key   string
+	Span  window.Span
+	Pane  *sink.Pane // Nil unless the trigger describes panes.
+	Every bool
+}
+
+// Spanner is implemented by triggers which signal windows
+// of keys rather than keys, so only the named windows are
+// emitted. StartSpans is called instead of Start.
+type Spanner interface {
+	StartSpans(signal func(kss []KeySpan) error) error
+}
+
+// keysOf the windows, each key once.
+func keysOf(kss []KeySpan) []string {
+	seen := map[string]bool{}
+	keys := []string{}
+	for _, ks := range kss {
+		if !seen[ks.Key] {
+			seen[ks.Key] = true
+			keys = append(keys, ks.Key)
+		}
+	}
+	return keys
+}
+
+// Triggers create the trigger of each graph instance, so
+// instances of the same graph type never share a trigger.
+type Triggers interface {
+	Setup(graphType, graphName string, conf []byte) (Trigger, error)
+}
+
+// SetupFunc creates the trigger of a graph instance, for
+// triggers configured by the instance's conf.
+type SetupFunc func(graphType, graphName string, conf []byte) (Trigger, error)
+
+// Setup the trigger by calling f.
+func (f SetupFunc) Setup(graphType, graphName string, conf []byte) (Trigger, error) {
+	return f(graphType, graphName, conf)
+}
+
+// Fresh triggers, f is called to create a new trigger for
+// each graph instance.
+func Fresh(f func() Trigger) Triggers {
+	return SetupFunc(func(graphType, graphName string, conf []byte) (Trigger, error) {
+		return f(), nil
+	})
+}
@@ -0,0 +1,317 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/sink"
+	"github.com/lytics/flo/window"
+)
+
+// AfterWatermark fires the time windows of keys in panes.
+// The on-time pane of a window is fired once the watermark
+// passes the end of the window. The watermark is the event
+// time every mapper has progressed past, see the graph's
+// OutOfOrder, so a mapper whose source is idle holds it
+// back until the source is done.
+// Early panes while the window is open, and late panes for
+// events arriving after, are only fired when configured.
+// Windows other than time windows are ignored.
+func AfterWatermark() *Watermark {
+	return &Watermark{
+		stop:    make(chan struct{}),
+		notify:  make(chan struct{}, 1),
+		windows: map[KeySpan]*paneState{},
+		logger:  log.New(os.Stderr, "watermark-trigger: ", log.LstdFlags),
+	}
+}
+
+// Watermark trigger, each window it fires is given to the
+// sinks with its pane, see sink.PaneOf.
+type Watermark struct {
+	discarder
+	mu        sync.Mutex
+	stop      chan struct{}
+	notify    chan struct{}
+	early     time.Duration
+	late      time.Duration
+	lateness  time.Duration
+	eos       bool
+	watermark time.Time
+	next      time.Time
+	lastEarly time.Time
+	lastLate  time.Time
+	logger    *log.Logger
+	signal    func([]KeySpan) error
+	windows   map[KeySpan]*paneState
+}
+
+// paneState of a window, until it closes.
+type paneState struct {
+	Index    int  `json:"index"`
+	OnTime   bool `json:"on_time"`
+	Modified bool `json:"modified"`
+}
+
+// Early panes of open windows modified since their last
+// pane, fired at most every period of processing time.
+func (t *Watermark) Early(every time.Duration) *Watermark {
+	t.early = every
+	return t
+}
+
+// Late panes of windows modified after their on-time pane,
+// fired at most every period of processing time. Without
+// late panes, late events are only fired by the final pane.
+func (t *Watermark) Late(every time.Duration) *Watermark {
+	t.late = every
+	return t
+}
+
+// Lateness allowed, a window closes once the watermark
+// passes its end plus the lateness, and its final pane is
+// fired. Events arriving for closed windows are not fired.
+// The default of zero makes the on-time pane final.
+func (t *Watermark) Lateness(d time.Duration) *Watermark {
+	t.lateness = d
+	return t
+}
+
+// Heuristic of the watermark fires on-time and final panes
+// of windows it passes, end of stream closes every window.
+func (t *Watermark) Heuristic(h *progress.Heuristic) {
+	t.mu.Lock()
+	if h.Watermark.After(t.watermark) {
+		t.watermark = h.Watermark
+	}
+	if h.EOS {
+		t.eos = true
+	}
+	passed := !t.next.IsZero() && !t.watermark.Before(t.next)
+	signal := t.signal
+	t.mu.Unlock()
+
+	// End of stream is fired before returning, so the
+	// final panes are fired when the heuristic is done.
+	if h.EOS && signal != nil {
+		kss := t.due(time.Now())
+		if len(kss) == 0 {
+			return
+		}
+		err := signal(kss)
+		if err != nil {
+			t.logger.Printf("failed firing final panes: %v", err)
+		}
+		return
+	}
+	if passed {
+		select {
+		case t.notify <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// Modified key, vs are the windows holding v.
+func (t *Watermark) Modified(key string, v interface{}, vs map[window.Span][]interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	for s := range vs {
+		if s.Kind() != window.Time {
+			continue
+		}
+		if !t.watermark.Before(s.End().Add(t.lateness)) {
+			continue
+		}
+		ks := KeySpan{Key: key, Span: s}
+		w, ok := t.windows[ks]
+		if !ok {
+			w = &paneState{OnTime: !t.watermark.Before(s.End())}
+			t.windows[ks] = w
+			t.wake(ks.Span, w)
+		}
+		w.Modified = true
+	}
+	return nil
+}
+
+// StartSpans of the trigger, signalling the panes of
+// windows.
+func (t *Watermark) StartSpans(signal func(kss []KeySpan) error) error {
+	t.mu.Lock()
+	t.signal = signal
+	t.mu.Unlock()
+
+	freq := 1 * time.Second
+	for _, d := range []time.Duration{t.early, t.late} {
+		if d > 0 && d < freq {
+			freq = d
+		}
+	}
+	if freq < 100*time.Millisecond {
+		freq = 100 * time.Millisecond
+	}
+	ticker := time.NewTicker(freq)
+	defer ticker.Stop()
+
+	for {
+		var now time.Time
+		select {
+		case <-t.stop:
+			return nil
+		case <-t.notify:
+			now = time.Now()
+		case now = <-ticker.C:
+		}
+		kss := t.due(now)
+		if len(kss) == 0 {
+			continue
+		}
+		err := signal(kss)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// Start the trigger, signalling the keys of windows, for
+// use where panes cannot be signaled.
+func (t *Watermark) Start(signal func(keys []string) error) error {
+	return t.StartSpans(func(kss []KeySpan) error {
+		return signal(keysOf(kss))
+	})
+}
+
+// due panes at the processing time now. Windows are no
+// longer tracked once their final pane is returned.
+func (t *Watermark) due(now time.Time) []KeySpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	early := t.early > 0 && now.Sub(t.lastEarly) >= t.early
+	if early {
+		t.lastEarly = now
+	}
+	late := t.late > 0 && now.Sub(t.lastLate) >= t.late
+	if late {
+		t.lastLate = now
+	}
+
+	var kss []KeySpan
+	t.next = time.Time{}
+	for ks, w := range t.windows {
+		end := ks.Span.End()
+		closed := t.eos || !t.watermark.Before(end.Add(t.lateness))
+
+		var timing sink.Timing
+		switch {
+		case !w.OnTime && (t.eos || !t.watermark.Before(end)):
+			timing = sink.OnTime
+			w.OnTime = true
+		case !w.OnTime && early && w.Modified:
+			timing = sink.Early
+		case w.OnTime && (closed || late && w.Modified):
+			timing = sink.Late
+		default:
+			t.wake(ks.Span, w)
+			continue
+		}
+
+		ks.Pane = &sink.Pane{Timing: timing, Index: w.Index, Final: closed}
+		kss = append(kss, ks)
+		w.Index++
+		w.Modified = false
+		if closed {
+			delete(t.windows, KeySpan{Key: ks.Key, Span: ks.Span})
+		} else {
+			t.wake(ks.Span, w)
+		}
+	}
+	return kss
+}
+
+// wake at the next watermark which fires a pane of the
+// window, its end or its closing.
+func (t *Watermark) wake(s window.Span, w *paneState) {
+	at := s.End()
+	if w.OnTime {
+		at = at.Add(t.lateness)
+	}
+	if t.next.IsZero() || at.Before(t.next) {
+		t.next = at
+	}
+}
+
+// Stop the trigger.
+func (t *Watermark) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.stop:
+		return
+	default:
+		close(t.stop)
+	}
+}
+
+// watermarkEntry of a checkpoint, spans cannot be keys
+// of JSON objects.
+type watermarkEntry struct {
+	Key  string      `json:"key"`
+	Span window.Span `json:"span"`
+	paneState
+}
+
+type watermarkCheckpoint struct {
+	Watermark time.Time        `json:"watermark"`
+	Windows   []watermarkEntry `json:"windows"`
+}
+
+// Checkpoint the watermark, and the panes of windows not
+// yet closed.
+func (t *Watermark) Checkpoint() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	c := watermarkCheckpoint{Watermark: t.watermark}
+	for ks, w := range t.windows {
+		c.Windows = append(c.Windows, watermarkEntry{Key: ks.Key, Span: ks.Span, paneState: *w})
+	}
+	return json.Marshal(c)
+}
+
+// Restore the watermark, and the panes of windows not yet
+// closed.
+func (t *Watermark) Restore(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var c watermarkCheckpoint
+	err := json.Unmarshal(data, &c)
+	if err != nil {
+		return fmt.Errorf("trigger: failed to restore: %v", err)
+	}
+	t.watermark = c.Watermark
+	t.windows = map[KeySpan]*paneState{}
+	t.next = time.Time{}
+	for _, e := range c.Windows {
+		w := e.paneState
+		t.windows[KeySpan{Key: e.Key, Span: e.Span}] = &w
+		t.wake(e.Span, &w)
+	}
+	return nil
+}
+
+// Discard the windows after each pane, so that each pane
+// only holds values since the last.
+func (t *Watermark) Discard() *Watermark {
+	t.discard = true
+	return t
+}
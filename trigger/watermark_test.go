@@ -0,0 +1,86 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/sink"
+	"github.com/lytics/flo/window"
+)
+
+func TestWatermarkPanes(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s := window.NewSpan(t0, t0.Add(1*time.Minute))
+	vs := map[window.Span][]interface{}{s: nil}
+
+	w := AfterWatermark().Early(1 * time.Minute).Late(1 * time.Minute).Lateness(10 * time.Minute)
+	now := time.Now()
+
+	expect := func(timing sink.Timing, index int, final bool) {
+		t.Helper()
+		kss := w.due(now)
+		if len(kss) != 1 || kss[0].Span != s {
+			t.Fatalf("expected one pane of: %v, found: %v", s, kss)
+		}
+		p := *kss[0].Pane
+		if p != (sink.Pane{Timing: timing, Index: index, Final: final}) {
+			t.Fatalf("expected %v pane: %v, final: %v, found: %+v", timing, index, final, p)
+		}
+	}
+	expectNone := func() {
+		t.Helper()
+		if kss := w.due(now); len(kss) != 0 {
+			t.Fatalf("expected no panes, found: %v", kss)
+		}
+	}
+
+	// Early pane while the window is open.
+	w.Modified("key", nil, vs)
+	expect(sink.Early, 0, false)
+
+	// Not modified since, or too soon.
+	now = now.Add(1 * time.Minute)
+	expectNone()
+	w.Modified("key", nil, vs)
+	now = now.Add(30 * time.Second)
+	expectNone()
+
+	// On-time pane once the watermark passes the end,
+	// even before the early period.
+	w.Heuristic(&progress.Heuristic{Watermark: t0.Add(1 * time.Minute)})
+	expect(sink.OnTime, 1, false)
+
+	// Late pane for stragglers.
+	w.Modified("key", nil, vs)
+	now = now.Add(1 * time.Minute)
+	expect(sink.Late, 2, false)
+
+	// Final pane once the lateness has passed, after
+	// which the window is closed to events.
+	w.Heuristic(&progress.Heuristic{Watermark: t0.Add(11 * time.Minute)})
+	expect(sink.Late, 3, true)
+	w.Modified("key", nil, vs)
+	now = now.Add(1 * time.Minute)
+	expectNone()
+}
+
+func TestWatermarkEOS(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s := window.NewSpan(t0, t0.Add(1*time.Minute))
+
+	w := AfterWatermark()
+	var fired []KeySpan
+	w.signal = func(kss []KeySpan) error {
+		fired = append(fired, kss...)
+		return nil
+	}
+	w.Modified("key", nil, map[window.Span][]interface{}{s: nil})
+
+	// End of stream fires the final pane before the
+	// heuristic returns.
+	w.Heuristic(&progress.Heuristic{EOS: true})
+	if len(fired) != 1 || *fired[0].Pane != (sink.Pane{Timing: sink.OnTime, Final: true}) {
+		t.Fatalf("expected final on-time pane, found: %v", fired)
+	}
+}
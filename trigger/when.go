@@ -0,0 +1,222 @@
+package trigger
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/lytics/flo/progress"
+	"github.com/lytics/flo/window"
+)
+
+// When the predicate f returns true for a window of a key,
+// given the window's current values, emit the window. For
+// example when a sum crosses a threshold.
+func When(f func(key string, span window.Span, vs []interface{}) bool) *Predicate {
+	return &Predicate{
+		f:       f,
+		stop:    make(chan struct{}),
+		notify:  make(chan struct{}, 1),
+		fired:   map[KeySpan]time.Time{},
+		pending: map[KeySpan]bool{},
+		logger:  log.New(os.Stderr, "when-trigger: ", log.LstdFlags),
+	}
+}
+
+// Predicate data trigger.
+type Predicate struct {
+	discarder
+	mu       sync.Mutex
+	f        func(key string, span window.Span, vs []interface{}) bool
+	stop     chan struct{}
+	notify   chan struct{}
+	debounce time.Duration
+	logger   *log.Logger
+	fired    map[KeySpan]time.Time
+	pending  map[KeySpan]bool
+}
+
+func (t *Predicate) Heuristic(*progress.Heuristic) {}
+
+// Inspecting is true, the predicate is given the values
+// of windows.
+func (t *Predicate) Inspecting() bool {
+	return true
+}
+
+// Modified key, the predicate is evaluated for each of the
+// windows vs holding v. Windows are signaled by Start, not
+// while the key is being modified.
+func (t *Predicate) Modified(key string, v interface{}, vs map[window.Span][]interface{}) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	for s, ws := range vs {
+		if !t.f(key, s, ws) {
+			continue
+		}
+		ks := KeySpan{Key: key, Span: s}
+		if t.debounce > 0 {
+			if last, ok := t.fired[ks]; ok && now.Sub(last) < t.debounce {
+				continue
+			}
+			t.fired[ks] = now
+		}
+		t.pending[ks] = true
+	}
+	if len(t.pending) > 0 {
+		select {
+		case t.notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// StartSpans of the trigger, signalling the windows for
+// which the predicate was true.
+func (t *Predicate) StartSpans(signal func(kss []KeySpan) error) error {
+	for {
+		select {
+		case <-t.stop:
+			return nil
+		case <-t.notify:
+			kss := t.take(time.Now())
+			if len(kss) == 0 {
+				continue
+			}
+			err := signal(kss)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// Start the trigger, signalling the keys of windows for
+// which the predicate was true.
+func (t *Predicate) Start(signal func(keys []string) error) error {
+	return t.StartSpans(func(kss []KeySpan) error {
+		return signal(keysOf(kss))
+	})
+}
+
+// take the pending windows, and forget windows fired
+// longer ago than the debounce.
+func (t *Predicate) take(now time.Time) []KeySpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	kss := make([]KeySpan, 0, len(t.pending))
+	for ks := range t.pending {
+		kss = append(kss, ks)
+	}
+	t.pending = map[KeySpan]bool{}
+
+	for ks, last := range t.fired {
+		if now.Sub(last) >= t.debounce {
+			delete(t.fired, ks)
+		}
+	}
+	return kss
+}
+
+// Stop the trigger.
+func (t *Predicate) Stop() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	select {
+	case <-t.stop:
+		return
+	default:
+		close(t.stop)
+	}
+}
+
+// Debounce the predicate, a window is fired at most once
+// within the duration, however often the predicate is
+// true for it.
+func (t *Predicate) Debounce(d time.Duration) *Predicate {
+	t.debounce = d
+	return t
+}
+
+// Discard the windows of keys after emitting them, so
+// that each emit only holds values since the last.
+func (t *Predicate) Discard() *Predicate {
+	t.discard = true
+	return t
+}
+
+// predicateEntry of a checkpoint, spans cannot be keys
+// of JSON objects.
+type predicateEntry struct {
+	Key     string      `json:"key"`
+	Span    window.Span `json:"span"`
+	Fired   time.Time   `json:"fired,omitempty"`
+	Pending bool        `json:"pending,omitempty"`
+}
+
+// Checkpoint the windows not yet signaled, and when
+// windows were last fired, for the debounce.
+func (t *Predicate) Checkpoint() ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	es := map[KeySpan]*predicateEntry{}
+	entry := func(ks KeySpan) *predicateEntry {
+		e, ok := es[ks]
+		if !ok {
+			e = &predicateEntry{Key: ks.Key, Span: ks.Span}
+			es[ks] = e
+		}
+		return e
+	}
+	for ks, last := range t.fired {
+		entry(ks).Fired = last
+	}
+	for ks := range t.pending {
+		entry(ks).Pending = true
+	}
+	c := make([]predicateEntry, 0, len(es))
+	for _, e := range es {
+		c = append(c, *e)
+	}
+	return json.Marshal(c)
+}
+
+// Restore the windows not yet signaled, and when windows
+// were last fired.
+func (t *Predicate) Restore(data []byte) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	var es []predicateEntry
+	err := json.Unmarshal(data, &es)
+	if err != nil {
+		return fmt.Errorf("trigger: failed to restore: %v", err)
+	}
+	t.fired = map[KeySpan]time.Time{}
+	t.pending = map[KeySpan]bool{}
+	for _, e := range es {
+		ks := KeySpan{Key: e.Key, Span: e.Span}
+		if !e.Fired.IsZero() {
+			t.fired[ks] = e.Fired
+		}
+		if e.Pending {
+			t.pending[ks] = true
+		}
+	}
+	if len(t.pending) > 0 {
+		select {
+		case t.notify <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
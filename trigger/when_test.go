@@ -0,0 +1,39 @@
+package trigger
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/window"
+)
+
+func TestWhenThreshold(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	s := window.NewSpan(t0, t0.Add(1*time.Minute))
+
+	over := func(key string, span window.Span, vs []interface{}) bool {
+		sum := 0
+		for _, v := range vs {
+			sum += v.(int)
+		}
+		return sum > 10
+	}
+
+	w := When(over).Debounce(1 * time.Hour)
+	w.Modified("key", 5, map[window.Span][]interface{}{s: {5}})
+	if kss := w.take(time.Now()); len(kss) != 0 {
+		t.Fatalf("expected nothing fired, found: %v", kss)
+	}
+
+	w.Modified("key", 7, map[window.Span][]interface{}{s: {5, 7}})
+	kss := w.take(time.Now())
+	if len(kss) != 1 || kss[0] != (KeySpan{Key: "key", Span: s}) {
+		t.Fatalf("expected window fired, found: %v", kss)
+	}
+
+	// Debounced, so not fired again.
+	w.Modified("key", 1, map[window.Span][]interface{}{s: {5, 7, 1}})
+	if kss := w.take(time.Now()); len(kss) != 0 {
+		t.Fatalf("expected debounced window, found: %v", kss)
+	}
+}
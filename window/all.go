@@ -9,13 +9,14 @@ import (
 // All of time window, which is considered to be the window of
 // time between the dates:
 //
-//             0001-01-01 00:00:00 +0000 UTC
-//     292277026596-01-01 00:00:00 +0000 UTC
+//          0001-01-01 00:00:00 +0000 UTC
+//     292277026-01-01 00:00:00 +0000 UTC
 //
-// Where the max is about 292 billion years in the future.
+// Where the max is about 292 million years in the future, which
+// is close to the max representable in a span.
 func All() Window {
 	min := time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC)
-	max := time.Date(292277026596, 1, 1, 0, 0, 0, 0, time.UTC)
+	max := time.Date(292277026, 1, 1, 0, 0, 0, 0, time.UTC)
 	return &all{
 		universe: NewSpan(min, max),
 	}
@@ -25,19 +26,19 @@ type all struct {
 	universe Span
 }
 
-func (w *all) Apply(time.Time) []Span {
+func (w *all) Apply(time.Time, interface{}) []Span {
 	return []Span{w.universe}
 }
 
 // Merge the new value vs into the universal window
 // in ss.
 func (w *all) Merge(s Span, v interface{}, ss State, f merger.ManyMerger) error {
-	vs := []interface{}{v}
-	vs0 := ss.Get(w.universe)
-	vs2, err := f(vs, vs0)
-	if err != nil {
-		return err
-	}
-	ss.Set(w.universe, vs2)
-	return nil
+	return MergeInto(w.universe, v, ss, f)
+}
+
+// Reset the universal window in ss, so that values
+// accumulate from scratch, for example to restart
+// a running total each time it is emitted.
+func (w *all) Reset(ss State) {
+	ss.Del(w.universe)
 }
@@ -14,11 +14,14 @@ func TestAllWindow(t *testing.T) {
 	all := All()
 
 	ss := newState()
-	all.Merge(ts, items(0), ss, appendMerge)
+	err := merge(all, ts, item(0), ss)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// The expected window of time is the single
 	// universal window.
-	expected := NewSpan(time.Date(01, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(292277026596, 1, 1, 0, 0, 0, 0, time.UTC))
+	expected := NewSpan(time.Date(01, 01, 01, 0, 0, 0, 0, time.UTC), time.Date(292277026, 1, 1, 0, 0, 0, 0, time.UTC))
 
 	vs := ss.Get(expected)
 	if vs == nil {
@@ -28,3 +31,29 @@ func TestAllWindow(t *testing.T) {
 		t.Fatal("expected data value")
 	}
 }
+
+func TestAllWindowReset(t *testing.T) {
+	ts := time.Date(2017, 01, 01, 13, 47, 1, 0, time.UTC)
+
+	all := All()
+
+	ss := newState()
+	for i := 0; i < 2; i++ {
+		err := merge(all, ts, item(i), ss)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// After a reset values accumulate from scratch.
+	all.(Resetter).Reset(ss)
+	err := merge(all, ts, item(2), ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	vs := ss.Get(all.Apply(ts, nil)[0])
+	if !equal(vs, items(2)) {
+		t.Fatalf("expected only values since reset, found: %v", vs)
+	}
+}
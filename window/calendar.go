@@ -0,0 +1,97 @@
+package window
+
+import (
+	"time"
+
+	"github.com/lytics/flo/merger"
+)
+
+type unit int
+
+const (
+	day unit = iota
+	week
+	month
+	quarter
+	year
+)
+
+// Daily window, from midnight to midnight in the
+// location. Days of a DST transition are shorter
+// or longer than 24 hours. A nil location is UTC.
+func Daily(loc *time.Location) Window {
+	return &calendar{unit: day, loc: location(loc)}
+}
+
+// Weekly window, starting at midnight of the start
+// day in the location. A nil location is UTC.
+func Weekly(start time.Weekday, loc *time.Location) Window {
+	return &calendar{unit: week, start: start, loc: location(loc)}
+}
+
+// Monthly window, from midnight of the first day of
+// the month in the location. A nil location is UTC.
+func Monthly(loc *time.Location) Window {
+	return &calendar{unit: month, loc: location(loc)}
+}
+
+// Quarterly window, starting January, April, July
+// and October in the location. A nil location is UTC.
+func Quarterly(loc *time.Location) Window {
+	return &calendar{unit: quarter, loc: location(loc)}
+}
+
+// Yearly window, from midnight of January first in
+// the location. A nil location is UTC.
+func Yearly(loc *time.Location) Window {
+	return &calendar{unit: year, loc: location(loc)}
+}
+
+type calendar struct {
+	unit  unit
+	start time.Weekday
+	loc   *time.Location
+}
+
+func (w *calendar) Apply(ts time.Time, v interface{}) []Span {
+	t := ts.In(w.loc)
+	y, m, d := t.Date()
+
+	// The start and end are computed from calendar
+	// dates, not by adding durations, so that they
+	// land on local midnight across DST changes.
+	var t0, t1 time.Time
+	switch w.unit {
+	case day:
+		t0 = time.Date(y, m, d, 0, 0, 0, 0, w.loc)
+		t1 = time.Date(y, m, d+1, 0, 0, 0, 0, w.loc)
+	case week:
+		back := (int(t.Weekday()) - int(w.start) + 7) % 7
+		t0 = time.Date(y, m, d-back, 0, 0, 0, 0, w.loc)
+		t1 = time.Date(y, m, d-back+7, 0, 0, 0, 0, w.loc)
+	case month:
+		t0 = time.Date(y, m, 1, 0, 0, 0, 0, w.loc)
+		t1 = time.Date(y, m+1, 1, 0, 0, 0, 0, w.loc)
+	case quarter:
+		q := time.Month((int(m)-1)/3*3 + 1)
+		t0 = time.Date(y, q, 1, 0, 0, 0, 0, w.loc)
+		t1 = time.Date(y, q+3, 1, 0, 0, 0, 0, w.loc)
+	case year:
+		t0 = time.Date(y, 1, 1, 0, 0, 0, 0, w.loc)
+		t1 = time.Date(y+1, 1, 1, 0, 0, 0, 0, w.loc)
+	}
+	return []Span{NewSpan(t0, t1)}
+}
+
+// Merge the new value vs into the appropriate existing windows
+// found in ss.
+func (w *calendar) Merge(s Span, v interface{}, ss State, f merger.ManyMerger) error {
+	return MergeInto(s, v, ss, f)
+}
+
+func location(loc *time.Location) *time.Location {
+	if loc == nil {
+		return time.UTC
+	}
+	return loc
+}
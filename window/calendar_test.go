@@ -0,0 +1,59 @@
+package window
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCalendarDailyDST(t *testing.T) {
+	denver, err := time.LoadLocation("America/Denver")
+	if err != nil {
+		t.Skip("time zone database not available:", err)
+	}
+
+	// Clocks in Denver sprang forward on 2017-03-12,
+	// so that day is only 23 hours long.
+	ts := time.Date(2017, 03, 12, 15, 0, 0, 0, denver)
+
+	ss := newState()
+	err = merge(Daily(denver), ts, item(0), ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := NewSpan(time.Date(2017, 03, 12, 0, 0, 0, 0, denver), time.Date(2017, 03, 13, 0, 0, 0, 0, denver))
+	if ss.Get(expected) == nil {
+		t.Fatalf("expected to find window: %v, found: %v", expected, ss.windows)
+	}
+	if d := expected.End().Sub(expected.Start()); d != 23*time.Hour {
+		t.Fatalf("expected 23 hour day, found: %v", d)
+	}
+}
+
+func TestCalendarWindows(t *testing.T) {
+	ts := time.Date(2017, 05, 17, 13, 47, 0, 0, time.UTC)
+
+	cases := []struct {
+		name   string
+		window Window
+		start  time.Time
+		end    time.Time
+	}{
+		{"daily", Daily(nil), time.Date(2017, 05, 17, 0, 0, 0, 0, time.UTC), time.Date(2017, 05, 18, 0, 0, 0, 0, time.UTC)},
+		{"weekly", Weekly(time.Monday, nil), time.Date(2017, 05, 15, 0, 0, 0, 0, time.UTC), time.Date(2017, 05, 22, 0, 0, 0, 0, time.UTC)},
+		{"weekly sunday", Weekly(time.Sunday, nil), time.Date(2017, 05, 14, 0, 0, 0, 0, time.UTC), time.Date(2017, 05, 21, 0, 0, 0, 0, time.UTC)},
+		{"monthly", Monthly(nil), time.Date(2017, 05, 1, 0, 0, 0, 0, time.UTC), time.Date(2017, 06, 1, 0, 0, 0, 0, time.UTC)},
+		{"quarterly", Quarterly(nil), time.Date(2017, 04, 1, 0, 0, 0, 0, time.UTC), time.Date(2017, 07, 1, 0, 0, 0, 0, time.UTC)},
+		{"yearly", Yearly(nil), time.Date(2017, 01, 1, 0, 0, 0, 0, time.UTC), time.Date(2018, 01, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		ws := c.window.Apply(ts, nil)
+		if len(ws) != 1 {
+			t.Fatalf("%v: expected one window, found: %v", c.name, ws)
+		}
+		if !ws[0].Equal(NewSpan(c.start, c.end)) {
+			t.Fatalf("%v: expected window: %v, found: %v", c.name, NewSpan(c.start, c.end), ws[0])
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package window
+
+import (
+	"time"
+
+	"github.com/lytics/flo/merger"
+)
+
+// AssignFn assigns an event, with time ts and data v,
+// to the spans of the windows it belongs to.
+type AssignFn func(ts time.Time, v interface{}) []Span
+
+// Assign window, defined only by how events are
+// assigned to spans. Each value is merged into the
+// values of every span it is assigned to, spans are
+// never merged with each other.
+func Assign(f AssignFn) Window {
+	return &assign{f: f}
+}
+
+// Merging window, defined by how events are assigned
+// to spans. Each value's span is merged with every stored
+// span it overlaps, along with their values, like
+// session windows.
+func Merging(f AssignFn) Window {
+	return &merging{f: f}
+}
+
+type assign struct {
+	f AssignFn
+}
+
+func (w *assign) Apply(ts time.Time, v interface{}) []Span {
+	return w.f(ts, v)
+}
+
+func (w *assign) Merge(s Span, v interface{}, ss State, f merger.ManyMerger) error {
+	return MergeInto(s, v, ss, f)
+}
+
+// Holding the event of span s is only s, the spans it
+// overlaps, such as spans nesting it, are apart.
+func (w *assign) Holding(s Span, ss State) map[Span][]interface{} {
+	return map[Span][]interface{}{s: ss.Get(s)}
+}
+
+type merging struct {
+	f AssignFn
+}
+
+func (w *merging) Apply(ts time.Time, v interface{}) []Span {
+	return w.f(ts, v)
+}
+
+func (w *merging) Merge(s Span, v interface{}, ss State, f merger.ManyMerger) error {
+	return MergeOverlapping(s, v, ss, f)
+}
+
+// MergeInto merges the value v into the values of span s
+// in the state, creating the span if it does not exist.
+func MergeInto(s Span, v interface{}, ss State, f merger.ManyMerger) error {
+	vs := []interface{}{v}
+	vs0 := ss.Get(s)
+	vs2, err := f(vs, vs0)
+	if err != nil {
+		return err
+	}
+	ss.Set(s, vs2)
+	return nil
+}
+
+// MergeOverlapping merges the value v and span s with
+// every span in the state that s overlaps, into a single
+// span covering all of them.
+func MergeOverlapping(s Span, v interface{}, prev State, f merger.ManyMerger) error {
+	var err error
+
+	// Check each existing window that
+	// overlaps with the new window 's'
+	// and merge the two together along
+	// with the data.
+	vs := []interface{}{v}
+	remove := map[Span]bool{}
+	for s0, vs0 := range prev.Overlapping(s) {
+		// Merge new data with existing
+		// data for overlapping windows.
+		vs, err = f(vs, vs0)
+		if err != nil {
+			return err
+		}
+		// Mark old window for removal.
+		remove[s0] = true
+		// Expand window, it will
+		// overlap removed window.
+		s = s.Expand(s0)
+	}
+
+	// Remove the old windows that
+	// have been merged, since they
+	// are no longer valid.
+	for s0 := range remove {
+		prev.Del(s0)
+	}
+
+	// Put the new possibly expanded and
+	// merged window into the state.
+	prev.Set(s, vs)
+
+	// Call it good.
+	return nil
+}
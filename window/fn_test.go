@@ -0,0 +1,37 @@
+package window
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAssignEventData(t *testing.T) {
+	// Tenant specific window widths, taken from
+	// the data of the event.
+	type event struct {
+		tenant string
+	}
+	widths := map[string]time.Duration{
+		"a": 10 * time.Minute,
+		"b": time.Hour,
+	}
+	tenants := Assign(func(ts time.Time, v interface{}) []Span {
+		width := widths[v.(event).tenant]
+		t0 := ts.Truncate(width)
+		return []Span{NewSpan(t0, t0.Add(width))}
+	})
+
+	ts := time.Date(2017, 01, 01, 13, 47, 1, 0, time.UTC)
+
+	a := tenants.Apply(ts, event{"a"})
+	b := tenants.Apply(ts, event{"b"})
+
+	expectedA := NewSpan(time.Date(2017, 01, 01, 13, 40, 0, 0, time.UTC), time.Date(2017, 01, 01, 13, 50, 0, 0, time.UTC))
+	expectedB := NewSpan(time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC), time.Date(2017, 01, 01, 14, 0, 0, 0, time.UTC))
+	if len(a) != 1 || !a[0].Equal(expectedA) {
+		t.Fatalf("expected window: %v, found: %v", expectedA, a)
+	}
+	if len(b) != 1 || !b[0].Equal(expectedB) {
+		t.Fatalf("expected window: %v, found: %v", expectedB, b)
+	}
+}
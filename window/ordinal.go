@@ -0,0 +1,109 @@
+package window
+
+import (
+	"math"
+	"time"
+
+	"github.com/lytics/flo/merger"
+)
+
+// anyOrdinal span overlaps every open ordinal span, and
+// anyClosed every closed one.
+var (
+	anyOrdinal = Span{0, math.MaxInt64, int64(Ordinal)}
+	anyClosed  = anyOrdinal.Close()
+)
+
+// Count window, closed after n events of a key. The
+// spans of the windows are the ordinals of the events
+// of the key, not times.
+func Count(n int) Window {
+	return &ordinal{count: int64(n)}
+}
+
+// Punctuation window, closed by the first event for
+// which end returns true, that event included. The
+// spans of the windows are the ordinals of the events
+// of the key, not times.
+func Punctuation(end func(v interface{}) bool) Window {
+	return &ordinal{end: end}
+}
+
+// ordinal windows of a key, at most one is open. Closed
+// windows take no more events, they are deleted once
+// emitted, after which ordinals of the key start over.
+type ordinal struct {
+	count int64
+	end   func(v interface{}) bool
+}
+
+// Apply returns a span of any ordinal window, the
+// window of the event depends on the windows of the
+// key, so it is chosen by Merge.
+func (w *ordinal) Apply(time.Time, interface{}) []Span {
+	return []Span{anyOrdinal}
+}
+
+// Merge the new value v into the open window of the
+// key, or into a new window if all are closed. Closed
+// windows are only read for the first event of a window,
+// to find the ordinal it starts at.
+func (w *ordinal) Merge(s Span, v interface{}, prev State, f merger.ManyMerger) error {
+	open, found := w.open(prev)
+
+	vs := []interface{}{v}
+	var n Span
+	if found {
+		var err error
+		vs, err = f(vs, prev.Get(open))
+		if err != nil {
+			return err
+		}
+		prev.Del(open)
+		n = NewOrdinalSpan(open[0], open[1]+1)
+	} else {
+		var next int64
+		for s0 := range prev.Overlapping(anyClosed) {
+			if s0[1] > next {
+				next = s0[1]
+			}
+		}
+		n = NewOrdinalSpan(next, next+1)
+	}
+
+	if w.count > 0 && n[1]-n[0] >= w.count {
+		n = n.Close()
+	}
+	if w.end != nil && w.end(v) {
+		n = n.Close()
+	}
+	prev.Set(n, vs)
+	return nil
+}
+
+// Holding the event merged last, which is the open window
+// or, when the event closed it, the latest closed window.
+func (w *ordinal) Holding(s Span, prev State) map[Span][]interface{} {
+	if open, found := w.open(prev); found {
+		return map[Span][]interface{}{open: prev.Get(open)}
+	}
+	var latest Span
+	var vs []interface{}
+	for s0, vs0 := range prev.Overlapping(anyClosed) {
+		if s0[1] > latest[1] {
+			latest, vs = s0, vs0
+		}
+	}
+	if vs == nil {
+		return nil
+	}
+	return map[Span][]interface{}{latest: vs}
+}
+
+// open window of the key, if any.
+func (w *ordinal) open(prev State) (Span, bool) {
+	for s0 := range prev.Overlapping(anyOrdinal) {
+		return s0, true
+	}
+	return Span{}, false
+}
@@ -0,0 +1,111 @@
+package window
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountWindow(t *testing.T) {
+	count := Count(2)
+
+	ss := newState()
+	for i := 0; i < 5; i++ {
+		err := merge(count, time.Now(), item(i), ss)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []struct {
+		Window Span
+		Data   []interface{}
+	}{
+		{NewOrdinalSpan(0, 2).Close(), []interface{}{item(0), item(1)}},
+		{NewOrdinalSpan(2, 4).Close(), []interface{}{item(2), item(3)}},
+		{NewOrdinalSpan(4, 5), []interface{}{item(4)}},
+	}
+
+	if len(ss.windows) != len(expected) {
+		t.Fatalf("expected %v windows, found: %v", len(expected), ss.windows)
+	}
+	for _, w := range expected {
+		if !equal(w.Data, ss.Get(w.Window)) {
+			t.Fatalf("expected window: %v, found: %v", w, ss.windows)
+		}
+	}
+}
+
+func TestPunctuationWindow(t *testing.T) {
+	// Multiples of 3 close the window, like
+	// a checkout ends a shopping window.
+	checkout := Punctuation(func(v interface{}) bool {
+		return v.(int)%3 == 0
+	})
+
+	ss := newState()
+	for i := 1; i <= 4; i++ {
+		err := merge(checkout, time.Now(), item(i), ss)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []struct {
+		Window Span
+		Data   []interface{}
+	}{
+		{NewOrdinalSpan(0, 3).Close(), []interface{}{item(1), item(2), item(3)}},
+		{NewOrdinalSpan(3, 4), []interface{}{item(4)}},
+	}
+
+	if len(ss.windows) != len(expected) {
+		t.Fatalf("expected %v windows, found: %v", len(expected), ss.windows)
+	}
+	for _, w := range expected {
+		if !equal(w.Data, ss.Get(w.Window)) {
+			t.Fatalf("expected window: %v, found: %v", w, ss.windows)
+		}
+	}
+}
+
+// closedReads counts the reads of closed windows.
+type closedReads struct {
+	*state
+	n int
+}
+
+func (ss *closedReads) Overlapping(s Span) map[Span][]interface{} {
+	if s.Kind() == Closed {
+		ss.n++
+	}
+	return ss.state.Overlapping(s)
+}
+
+func TestOrdinalHolding(t *testing.T) {
+	count := Count(2).(*ordinal)
+
+	ss := &closedReads{state: newState()}
+	for i := 0; i < 3; i++ {
+		err := merge(count, time.Now(), item(i), ss)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// The closing event is held by the closed
+		// window, the others by the open one.
+		expected := NewOrdinalSpan(int64(i/2*2), int64(i+1))
+		if i == 1 {
+			expected = expected.Close()
+		}
+		held := count.Holding(anyOrdinal, ss)
+		if _, ok := held[expected]; !ok || len(held) != 1 {
+			t.Fatalf("expected event %v held by: %v, found: %v", i, expected, held)
+		}
+	}
+
+	// Closed windows are only read by the first event
+	// of each window, and when holding the event which
+	// closed a window.
+	if ss.n != 3 {
+		t.Fatalf("expected three reads of closed windows, found: %v", ss.n)
+	}
+}
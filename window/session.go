@@ -6,6 +6,10 @@ import (
 	"github.com/lytics/flo/merger"
 )
 
+// SessionGap of inactivity after the event v, after
+// which its session is closed.
+type SessionGap func(v interface{}) time.Duration
+
 // Session window based on a last activity timeout.
 func Session(timeout time.Duration) Window {
 	return &session{
@@ -13,63 +17,104 @@ func Session(timeout time.Duration) Window {
 	}
 }
 
+// SessionFunc window where the gap of inactivity is
+// computed from each event. When max is greater than
+// zero, no session is longer than max, events at or
+// after the max start a new session.
+func SessionFunc(gap SessionGap, max time.Duration) Window {
+	return &session{
+		gap: gap,
+		max: max,
+	}
+}
+
 type session struct {
 	timeout time.Duration
+	gap     SessionGap
+	max     time.Duration
 }
 
-func (w *session) apply(ts time.Time) Span {
-	// Truncated timestamp.
-	tts := ts.Truncate(1 * time.Minute)
-	// Min and max of session window.
-	min := tts
-	max := tts.Add(w.timeout)
-	return NewSpan(min, max)
+// span of the session started by a single event
+// at ts with data v.
+func (w *session) span(ts time.Time, v interface{}) Span {
+	gap := w.timeout
+	if w.gap != nil {
+		gap = w.gap(v)
+	}
+	end := ts.Add(gap)
+	if w.max > 0 && end.After(ts.Add(w.max)) {
+		end = ts.Add(w.max)
+	}
+	return NewSpan(ts, end)
 }
 
-func (w *session) Apply(ts time.Time) []Span {
-	return []Span{w.apply(ts)}
+func (w *session) Apply(ts time.Time, v interface{}) []Span {
+	return []Span{w.span(ts, v)}
 }
 
 // Merge the new value v into the appropriate existing
 // windows in previous state, possibly expanding some
 // existing windows.
 func (w *session) Merge(s Span, v interface{}, prev State, f merger.ManyMerger) error {
-	var err error
+	overlapping := prev.Overlapping(s)
 
-	// Check each existing window, and if it
-	// overlaps with the new window 's'
-	// merge the two together along with
-	// the data.
-	vs := []interface{}{v}
-	remove := map[Span]bool{}
-	for s0 := range prev.Windows() {
-		vs0 := prev.Get(s0)
-		if s.Overlap(s0) {
-			// Merge new data with existing
-			// data for overlapping windows.
-			vs, err = f(vs, vs0)
-			if err != nil {
-				return err
-			}
-			// Mark old window for removal.
-			remove[s0] = true
-			// Expand window, it will
-			// overlap removed window.
-			s = s.Expand(s0)
+	// When merging would grow the session
+	// past its max length, the event is
+	// split from the sessions instead.
+	if w.max > 0 {
+		c := s
+		for s0 := range overlapping {
+			c = c.Expand(s0)
+		}
+		if c.End().Sub(c.Start()) > w.max {
+			return w.split(s, v, overlapping, prev, f)
 		}
 	}
 
-	// Remove the old sessions windows
-	// that have been merged, since
-	// they are no longer valid.
-	for s0 := range remove {
+	return MergeOverlapping(s, v, prev, f)
+}
+
+// split the event with session s from the overlapping
+// sessions. The event joins the session it falls in,
+// extended up to its max, or otherwise starts a new
+// session. Either way sessions never overlap.
+func (w *session) split(s Span, v interface{}, overlapping map[Span][]interface{}, prev State, f merger.ManyMerger) error {
+	ts := s.Start()
+	for s0, vs0 := range overlapping {
+		if ts.Before(s0.Start()) || !ts.Before(s0.End()) {
+			continue
+		}
+		end := s.End()
+		if limit := s0.Start().Add(w.max); end.After(limit) {
+			end = limit
+		}
+		n := NewSpan(s0.Start(), s0.End())
+		if end.After(n.End()) {
+			n = NewSpan(n.Start(), end)
+		}
+		n = clip(n, s0, overlapping)
+		vs, err := f([]interface{}{v}, vs0)
+		if err != nil {
+			return err
+		}
 		prev.Del(s0)
+		prev.Set(n, vs)
+		return nil
 	}
-
-	// Put the new possibly expanded and
-	// merged session into the map.
-	prev.Set(s, vs)
-
-	// Call it good.
+	prev.Set(clip(s, zero, overlapping), []interface{}{v})
 	return nil
 }
+
+// clip the end of n to the start of the first of the
+// other sessions starting within it.
+func clip(n, self Span, others map[Span][]interface{}) Span {
+	for s1 := range others {
+		if s1.Equal(self) {
+			continue
+		}
+		if s1[0] > n[0] && s1[0] < n[1] {
+			n[1] = s1[0]
+		}
+	}
+	return n
+}
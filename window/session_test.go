@@ -25,7 +25,7 @@ func TestSessionWindow(t *testing.T) {
 	// correctly.
 	ss := newState()
 	for i, ts := range times {
-		err := session.Merge(ts, items(i), ss, appendMerge)
+		err := merge(session, ts, item(i), ss)
 		if err != nil {
 			t.Fatal(err)
 		}
@@ -62,3 +62,83 @@ func TestSessionWindow(t *testing.T) {
 		}
 	}
 }
+
+func TestSessionWindowGapFunc(t *testing.T) {
+	// Odd items get a gap of an hour, even
+	// items a gap of 10 minutes.
+	gap := func(v interface{}) time.Duration {
+		if v.(int)%2 == 1 {
+			return time.Hour
+		}
+		return 10 * time.Minute
+	}
+	session := SessionFunc(gap, 0)
+
+	times := []time.Time{
+		time.Date(2017, 02, 17, 13, 0, 0, 0, time.UTC),  // Session A
+		time.Date(2017, 02, 17, 13, 5, 0, 0, time.UTC),  // Session A
+		time.Date(2017, 02, 17, 13, 50, 0, 0, time.UTC), // Session A, within the hour gap
+	}
+
+	ss := newState()
+	for i, ts := range times {
+		err := merge(session, ts, item(i), ss)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := NewSpan(time.Date(2017, 02, 17, 13, 0, 0, 0, time.UTC), time.Date(2017, 02, 17, 14, 5, 0, 0, time.UTC))
+	if len(ss.windows) != 1 {
+		t.Fatalf("expected one session, found: %v", ss.windows)
+	}
+	if !equal(ss.Get(expected), []interface{}{item(0), item(1), item(2)}) {
+		t.Fatalf("expected session: %v, found: %v", expected, ss.windows)
+	}
+}
+
+func TestSessionWindowMax(t *testing.T) {
+	gap := func(interface{}) time.Duration { return 30 * time.Minute }
+	session := SessionFunc(gap, time.Hour)
+
+	// Events every 20 minutes would make a single
+	// session, but sessions are at most an hour.
+	times := []time.Time{
+		time.Date(2017, 02, 17, 13, 0, 0, 0, time.UTC),  // Session A
+		time.Date(2017, 02, 17, 13, 20, 0, 0, time.UTC), // Session A
+		time.Date(2017, 02, 17, 13, 40, 0, 0, time.UTC), // Session A
+		time.Date(2017, 02, 17, 14, 0, 0, 0, time.UTC),  // Session B
+		time.Date(2017, 02, 17, 14, 20, 0, 0, time.UTC), // Session B
+	}
+
+	ss := newState()
+	for i, ts := range times {
+		err := merge(session, ts, item(i), ss)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	expected := []struct {
+		Session Span
+		Data    []interface{}
+	}{
+		{
+			Session: NewSpan(time.Date(2017, 02, 17, 13, 0, 0, 0, time.UTC), time.Date(2017, 02, 17, 14, 0, 0, 0, time.UTC)),
+			Data:    []interface{}{item(0), item(1), item(2)},
+		},
+		{
+			Session: NewSpan(time.Date(2017, 02, 17, 14, 0, 0, 0, time.UTC), time.Date(2017, 02, 17, 14, 50, 0, 0, time.UTC)),
+			Data:    []interface{}{item(3), item(4)},
+		},
+	}
+
+	if len(ss.windows) != len(expected) {
+		t.Fatalf("expected %v sessions, found: %v", len(expected), ss.windows)
+	}
+	for _, s := range expected {
+		if !equal(s.Data, ss.Get(s.Session)) {
+			t.Fatalf("expected session: %v, found: %v", s, ss.windows)
+		}
+	}
+}
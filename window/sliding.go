@@ -1,6 +1,7 @@
 package window
 
 import (
+	"sort"
 	"time"
 
 	"github.com/lytics/flo/merger"
@@ -9,38 +10,37 @@ import (
 // Sliding window, with a width and period. When
 // the period is smaller than the width, multiple
 // windows are produced for each timestamp.
+//
+// Values are stored once, in non-overlapping panes
+// the size of the greatest common divisor of width
+// and period, and the sliding windows are assembled
+// from the panes when emitted.
 func Sliding(width, period time.Duration) Window {
-	return &sliding{width: width, period: period}
+	return &sliding{width: width, period: period, pane: gcd(width, period)}
 }
 
 type sliding struct {
 	width  time.Duration
 	period time.Duration
+	pane   time.Duration
 }
 
-func (w *sliding) Apply(ts time.Time) []Span {
-	// Truncated timestamp.
-	tts := ts.Truncate(1 * time.Minute).Truncate(w.width)
-	// Min and max of window.
-	min := tts
-	max := tts.Add(w.width)
+// Apply returns the pane of the timestamp.
+func (w *sliding) Apply(ts time.Time, v interface{}) []Span {
+	p := ts.Truncate(w.pane)
+	return []Span{NewSpan(p, p.Add(w.pane))}
+}
 
-	// When the period and width are of
-	// different sizes, ie: the period
-	// is smaller than the width,
-	// multiple windows are produced.
+// windows the timestamp belongs to, one for every start
+// aligned to the period within the width before it, in
+// order of start.
+func (w *sliding) windows(ts time.Time) []Span {
 	var ws []Span
-	t0 := min
-	t1 := max
-	for {
-		if (ts.Equal(t0) || ts.After(t0)) && ts.Before(t1) {
-			ws = append(ws, NewSpan(t0, t1))
-		}
-		t0 = t0.Add(w.period)
-		t1 = t1.Add(w.period)
-		if t0.After(ts) {
-			break
-		}
+	for t0 := ts.Truncate(w.period); ts.Sub(t0) < w.width; t0 = t0.Add(-w.period) {
+		ws = append(ws, NewSpan(t0, t0.Add(w.width)))
+	}
+	for i, j := 0, len(ws)-1; i < j; i, j = i+1, j-1 {
+		ws[i], ws[j] = ws[j], ws[i]
 	}
 	return ws
 }
@@ -48,12 +48,54 @@ func (w *sliding) Apply(ts time.Time) []Span {
 // Merge the new value vs into the appropriate existing windows
 // found in ss.
 func (w *sliding) Merge(s Span, v interface{}, ss State, f merger.ManyMerger) error {
-	vs := []interface{}{v}
-	vs0 := ss.Get(s)
-	vs2, err := f(vs, vs0)
-	if err != nil {
-		return err
+	return MergeInto(s, v, ss, f)
+}
+
+// Assemble the sliding windows which hold any of the
+// panes, by merging the panes of each window in order.
+func (w *sliding) Assemble(panes map[Span][]interface{}, f merger.ManyMerger) (map[Span][]interface{}, error) {
+	order := make([]Span, 0, len(panes))
+	for p := range panes {
+		order = append(order, p)
+	}
+	sort.Slice(order, func(i, j int) bool { return order[i][0] < order[j][0] })
+
+	ws := map[Span][]interface{}{}
+	for _, p := range order {
+		for _, s := range w.windows(p.Start()) {
+			if _, ok := ws[s]; ok {
+				continue
+			}
+			var vs []interface{}
+			i := sort.Search(len(order), func(i int) bool { return order[i][0] >= s[0] })
+			for _, p0 := range order[i:] {
+				if p0[0] >= s[1] {
+					break
+				}
+				if vs == nil {
+					vs = append([]interface{}(nil), panes[p0]...)
+					continue
+				}
+				var err error
+				vs, err = f(panes[p0], vs)
+				if err != nil {
+					return nil, err
+				}
+			}
+			ws[s] = vs
+		}
+	}
+	return ws, nil
+}
+
+// Covering sliding windows of the pane.
+func (w *sliding) Covering(pane Span) []Span {
+	return w.windows(pane.Start())
+}
+
+func gcd(a, b time.Duration) time.Duration {
+	for b != 0 {
+		a, b = b, a%b
 	}
-	ss.Set(s, vs2)
-	return nil
+	return a
 }
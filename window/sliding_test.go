@@ -1,6 +1,7 @@
 package window
 
 import (
+	"reflect"
 	"testing"
 	"time"
 )
@@ -16,17 +17,30 @@ func TestSlidingWindow(t *testing.T) {
 	sliding := Sliding(5*time.Minute, 2*time.Minute)
 
 	ss := newState()
-	sliding.Merge(ts, items(0), ss, appendMerge)
+	err := merge(sliding, ts, item(0), ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The value is stored once, in a pane, and the
+	// windows are assembled from the panes.
+	ws, err := sliding.(Assembler).Assemble(ss.windows, appendMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	// Check that both expected windows were produced
-	// from the timestamp.
+	// from the timestamp, starting on the period.
 	expected := []Span{
-		NewSpan(time.Date(2017, 01, 01, 13, 45, 0, 0, time.UTC), time.Date(2017, 01, 01, 13, 50, 0, 0, time.UTC)),
-		NewSpan(time.Date(2017, 01, 01, 13, 47, 0, 0, time.UTC), time.Date(2017, 01, 01, 13, 52, 0, 0, time.UTC)),
+		NewSpan(time.Date(2017, 01, 01, 13, 44, 0, 0, time.UTC), time.Date(2017, 01, 01, 13, 49, 0, 0, time.UTC)),
+		NewSpan(time.Date(2017, 01, 01, 13, 46, 0, 0, time.UTC), time.Date(2017, 01, 01, 13, 51, 0, 0, time.UTC)),
+	}
+	if len(ws) != len(expected) {
+		t.Fatalf("expected windows: %v, found: %v", expected, ws)
 	}
 
 	for _, s := range expected {
-		vs := ss.Get(s)
+		vs := ws[s]
 		if vs == nil {
 			t.Fatal("expected to find window:", s)
 		}
@@ -35,3 +49,102 @@ func TestSlidingWindow(t *testing.T) {
 		}
 	}
 }
+
+func TestSlidingWindowSubMinute(t *testing.T) {
+	// Windows narrower than a minute must not be
+	// stretched to the minute.
+	ts := time.Date(2017, 01, 01, 13, 47, 1, 250*int(time.Millisecond), time.UTC)
+
+	sliding := Sliding(10*time.Second, 10*time.Second)
+
+	ss := newState()
+	err := merge(sliding, ts, item(0), ss)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := NewSpan(time.Date(2017, 01, 01, 13, 47, 0, 0, time.UTC), time.Date(2017, 01, 01, 13, 47, 10, 0, time.UTC))
+	if len(ss.windows) != 1 {
+		t.Fatalf("expected one window, found: %v", ss.windows)
+	}
+	if ss.Get(expected) == nil {
+		t.Fatal("expected to find window:", expected)
+	}
+}
+
+func TestSlidingWindowPanes(t *testing.T) {
+	// An hour wide window, sliding every 10 minutes,
+	// would hold each value in 6 windows.
+	sliding := Sliding(time.Hour, 10*time.Minute)
+
+	t0 := time.Date(2017, 01, 01, 13, 0, 0, 0, time.UTC)
+	ss := newState()
+	for i := 0; i < 12; i++ {
+		err := merge(sliding, t0.Add(time.Duration(i)*10*time.Minute), item(i), ss)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Each value is only stored once.
+	stored := 0
+	for _, vs := range ss.windows {
+		stored += len(vs)
+	}
+	if stored != 12 {
+		t.Fatalf("expected 12 stored values, found: %v", stored)
+	}
+
+	ws, err := sliding.(Assembler).Assemble(ss.windows, appendMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := NewSpan(time.Date(2017, 01, 01, 13, 30, 0, 0, time.UTC), time.Date(2017, 01, 01, 14, 30, 0, 0, time.UTC))
+	if !equal(ws[expected], []interface{}{item(3), item(4), item(5), item(6), item(7), item(8)}) {
+		t.Fatalf("expected window: %v, found: %v", expected, ws[expected])
+	}
+}
+
+func TestSlidingCovering(t *testing.T) {
+	at := func(h, m int) time.Time {
+		return time.Date(2017, 01, 01, h, m, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		width, period time.Duration
+		pane          Span
+		expected      []Span
+	}{
+		// Windows started before the pane's width boundary.
+		{10 * time.Minute, 5 * time.Minute, NewSpan(at(12, 10), at(12, 15)),
+			[]Span{NewSpan(at(12, 5), at(12, 15)), NewSpan(at(12, 10), at(12, 20))}},
+		{15 * time.Minute, 5 * time.Minute, NewSpan(at(12, 10), at(12, 15)),
+			[]Span{NewSpan(at(12, 0), at(12, 15)), NewSpan(at(12, 5), at(12, 20)), NewSpan(at(12, 10), at(12, 25))}},
+		{10 * time.Minute, 4 * time.Minute, NewSpan(at(12, 6), at(12, 8)),
+			[]Span{NewSpan(at(12, 0), at(12, 10)), NewSpan(at(12, 4), at(12, 14))}},
+		// Tumbling.
+		{10 * time.Minute, 10 * time.Minute, NewSpan(at(12, 10), at(12, 20)),
+			[]Span{NewSpan(at(12, 10), at(12, 20))}},
+	}
+	for _, tt := range tests {
+		w := Sliding(tt.width, tt.period)
+		covering := w.(Assembler).Covering(tt.pane)
+		if !reflect.DeepEqual(covering, tt.expected) {
+			t.Fatalf("%v/%v: expected windows covering %v: %v, found: %v", tt.width, tt.period, tt.pane, tt.expected, covering)
+		}
+
+		// The assembled windows of the pane are the
+		// covering windows.
+		ws, err := w.(Assembler).Assemble(map[Span][]interface{}{tt.pane: {item(0)}}, appendMerge)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ws) != len(tt.expected) {
+			t.Fatalf("%v/%v: expected assembled windows: %v, found: %v", tt.width, tt.period, tt.expected, ws)
+		}
+		for _, s := range tt.expected {
+			if !equal(ws[s], []interface{}{item(0)}) {
+				t.Fatalf("%v/%v: expected window: %v, found: %v", tt.width, tt.period, s, ws[s])
+			}
+		}
+	}
+}
@@ -10,72 +10,192 @@ var (
 	zero = Span{}
 )
 
-// NewSpan from the start and end times.
+const (
+	// SpanKeyV1 is the legacy key version, which holds
+	// the start and end of the span in Unix seconds.
+	SpanKeyV1 = 1
+	// SpanKeyV2 is the key version which is prefixed by
+	// its length, and holds the kind of span, along with
+	// its start and end in Unix milliseconds, which may
+	// be negative.
+	SpanKeyV2 = 2
+	// SpanKeyCurrent is the version of keys produced by Key.
+	SpanKeyCurrent = SpanKeyV2
+)
+
+// SpanKeyVersions from newest to oldest.
+var SpanKeyVersions = []int{SpanKeyV2, SpanKeyV1}
+
+// signBit is flipped in keys so that negative values
+// sort before positive ones.
+const signBit = 1 << 63
+
+// Kind of window identified by a span.
+type Kind int64
+
+const (
+	// Time windows span Unix milliseconds.
+	Time Kind = iota
+	// Ordinal windows span the ordinals of the
+	// events of a key, and are still open.
+	Ordinal
+	// Closed ordinal windows take no more events.
+	Closed
+)
+
+// NewSpan from the start and end times. The span has
+// millisecond precision, finer parts of start and
+// end are truncated.
 func NewSpan(start, end time.Time) Span {
-	return Span{start.Unix(), end.Unix()}
+	return Span{millis(start), millis(end)}
 }
 
-// NewSpanFromKey produced by a previous call to Key.
+// NewSpanFromKey produced by a previous call to Key,
+// of any key version.
 func NewSpanFromKey(key []byte) (Span, error) {
-	if key[8] != ':' {
+	switch SpanKeyVersion(key) {
+	case SpanKeyV2:
+		t0 := int64(binary.BigEndian.Uint64(key[3:11]) ^ signBit)
+		t1 := int64(binary.BigEndian.Uint64(key[11:19]) ^ signBit)
+		return Span{t0, t1, int64(key[2])}, nil
+	case SpanKeyV1:
+		t0 := int64(binary.BigEndian.Uint64(key[0:8]))
+		t1 := int64(binary.BigEndian.Uint64(key[9:17]))
+		// Legacy keys can not hold negative values,
+		// any found are corrupt.
+		if t0 < 0 || t1 < 0 {
+			return zero, fmt.Errorf("span: negative time value")
+		}
+		return Span{t0 * 1000, t1 * 1000}, nil
+	default:
 		return zero, fmt.Errorf("span: not a valid span key")
 	}
-	t0 := int64(binary.BigEndian.Uint64(key[0:8]))
-	t1 := int64(binary.BigEndian.Uint64(key[9:17]))
-	if t0 < 0 {
-		return zero, fmt.Errorf("span: negative time value")
+}
+
+// SpanKeyVersion of the key, or zero if the key
+// is not a span key.
+func SpanKeyVersion(key []byte) int {
+	switch {
+	case len(key) == 19 && int(key[0]) == len(key)-1 && key[1] == SpanKeyV2:
+		return SpanKeyV2
+	case len(key) == 17 && key[8] == ':':
+		return SpanKeyV1
+	default:
+		return 0
 	}
-	if t1 < 0 {
-		return zero, fmt.Errorf("span: negative time value")
+}
+
+// SpanKeySize in bytes of keys of the given version.
+func SpanKeySize(version int) int {
+	switch version {
+	case SpanKeyV1:
+		return 8 + 1 + 8
+	case SpanKeyV2:
+		return 1 + 1 + 1 + 8 + 8
+	default:
+		return 0
 	}
-	return Span{t0, t1}, nil
 }
 
 // Span of time defining a window. The first element
 // is the start,inclusive, and the second element is
 // the end, exclusive. In other words: [start,end)
-type Span [2]int64
+// Both are in Unix milliseconds. The third element
+// is the kind of window, for windows other than
+// time windows the start and end are ordinals.
+type Span [3]int64
+
+// NewOrdinalSpan of the events with ordinals in
+// [start,end) of a key.
+func NewOrdinalSpan(start, end int64) Span {
+	return Span{start, end, int64(Ordinal)}
+}
+
+// Kind of window the span identifies.
+func (s Span) Kind() Kind {
+	return Kind(s[2])
+}
+
+// Close an ordinal span, time spans are
+// returned as is.
+func (s Span) Close() Span {
+	if s.Kind() == Ordinal {
+		s[2] = int64(Closed)
+	}
+	return s
+}
 
 // Start of the span.
 func (s Span) Start() time.Time {
-	return time.Unix(s[0], 0)
+	return fromMillis(s[0])
 }
 
 // End of the span.
 func (s Span) End() time.Time {
-	return time.Unix(s[1], 0)
+	return fromMillis(s[1])
 }
 
-// Key of span usable as a datastore identifier.
+// Key of span usable as a datastore identifier. Keys
+// of the same version sort in order of kind, then
+// span start, including negative starts. The format
+// is:
+//
+//	<length> <version> <kind> <start> <end>
+//
+// Where the length is of the rest of the key, and
+// the start and end are big endian with their sign
+// bit flipped.
 func (s Span) Key() ([]byte, error) {
-	if s[0] < 0 {
-		return nil, fmt.Errorf("span: negative time value")
+	key := make([]byte, SpanKeySize(SpanKeyCurrent))
+	key[0] = byte(len(key) - 1)
+	key[1] = SpanKeyCurrent
+	key[2] = byte(s[2])
+	binary.BigEndian.PutUint64(key[3:], uint64(s[0])^signBit)
+	binary.BigEndian.PutUint64(key[11:], uint64(s[1])^signBit)
+	return key, nil
+}
+
+// Contains is true when t is in the span, in other
+// words start <= t < end. Only time spans contain
+// times.
+func (s Span) Contains(t time.Time) bool {
+	if s.Kind() != Time {
+		return false
 	}
-	if s[1] < 0 {
-		return nil, fmt.Errorf("span: negative time value")
+	return !t.Before(s.Start()) && t.Before(s.End())
+}
+
+// Duration of the span, zero for spans which are
+// not time spans.
+func (s Span) Duration() time.Duration {
+	if s.Kind() != Time {
+		return 0
 	}
-	key := make([]byte, 8+8+1)
-	binary.BigEndian.PutUint64(key[0:], uint64(s[0]))
-	key[8] = ':'
-	binary.BigEndian.PutUint64(key[9:], uint64(s[1]))
-	return key, nil
+	return time.Duration(s[1]-s[0]) * time.Millisecond
 }
 
 // String of the span.
 func (s Span) String() string {
-	return fmt.Sprintf("[%v,%v)", time.Unix(s[0], 0), time.Unix(s[1], 0))
+	switch s.Kind() {
+	case Ordinal:
+		return fmt.Sprintf("#[%v,%v)", s[0], s[1])
+	case Closed:
+		return fmt.Sprintf("#[%v,%v]", s[0], s[1]-1)
+	}
+	return fmt.Sprintf("[%v,%v)", s.Start(), s.End())
 }
 
 // Equal when this span and r have the same
-// start and end times.
+// start and end times, and are of the same
+// kind.
 func (s Span) Equal(r Span) bool {
-	return s[0] == r[0] && s[1] == r[1]
+	return s[0] == r[0] && s[1] == r[1] && s[2] == r[2]
 }
 
 // Expand this span and r into a new span
 // that covers both.
 func (s Span) Expand(r Span) Span {
-	n := Span{s[0], s[1]}
+	n := Span{s[0], s[1], s[2]}
 	if r[0] < s[0] {
 		n[0] = r[0]
 	}
@@ -86,8 +206,17 @@ func (s Span) Expand(r Span) Span {
 }
 
 // Overlap returns true when this span and r
-// have an overlap.
+// have an overlap. Spans of different kinds
+// never overlap, so neither do time and
+// ordinal spans, nor open and closed ones.
 func (s Span) Overlap(r Span) bool {
+	if s.Kind() != r.Kind() {
+		return false
+	}
+	// Both spans are half-open, so spans which only
+	// touch do not overlap, and spans with no width
+	// overlap nothing.
+	//
 	// TRUE IF:
 	//     s = [10:13, 10:23)
 	//     r =   [10:17, 10:27)
@@ -98,7 +227,7 @@ func (s Span) Overlap(r Span) bool {
 	//     s = [10:13, 10:23)
 	//     r = [10:13, 10:23)
 	//
-	// FASE IF:
+	// FALSE IF:
 	//     s = [10:13, 10:23)
 	//     r =        [10:23, 10:33)
 	// OR
@@ -107,11 +236,21 @@ func (s Span) Overlap(r Span) bool {
 	// OR
 	//     s =                 [10:30, 10:33)
 	//     r = [10:13, 10:20)
-	if s.Start().Equal(r.Start()) {
-		return true
-	}
-	if s.Start().Before(r.Start()) {
-		return r.Start().Before(s.End())
+	return s[0] < r[1] && r[0] < s[1]
+}
+
+// millis since the Unix epoch of t, rounded down.
+func millis(t time.Time) int64 {
+	return t.Unix()*1000 + int64(t.Nanosecond())/int64(time.Millisecond)
+}
+
+// fromMillis since the Unix epoch to a time.
+func fromMillis(ms int64) time.Time {
+	sec := ms / 1000
+	rem := ms % 1000
+	if rem < 0 {
+		sec--
+		rem += 1000
 	}
-	return s.Start().Before(r.End())
+	return time.Unix(sec, rem*int64(time.Millisecond))
 }
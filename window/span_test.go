@@ -1,6 +1,8 @@
 package window
 
 import (
+	"bytes"
+	"encoding/binary"
 	"testing"
 	"time"
 )
@@ -21,3 +23,118 @@ func TestSpanKey(t *testing.T) {
 		t.Fatalf("expected spans to be equal: %v != %v", s0, s1)
 	}
 }
+
+func TestSpanKeyMillis(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 47, 1, 250*int(time.Millisecond), time.UTC)
+
+	s0 := NewSpan(t0, t0.Add(500*time.Millisecond))
+	k0, err := s0.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if SpanKeyVersion(k0) != SpanKeyCurrent {
+		t.Fatalf("expected key version: %v, found: %v", SpanKeyCurrent, SpanKeyVersion(k0))
+	}
+	s1, err := NewSpanFromKey(k0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s1.Start().Equal(t0) || !s1.End().Equal(t0.Add(500*time.Millisecond)) {
+		t.Fatalf("expected millisecond precision: %v", s1)
+	}
+}
+
+func TestSpanKeyLegacy(t *testing.T) {
+	t0 := time.Date(2017, 01, 01, 13, 47, 0, 0, time.UTC)
+	t1 := t0.Add(10 * time.Minute)
+
+	// Legacy keys hold seconds: <start>:<end>
+	k0 := make([]byte, SpanKeySize(SpanKeyV1))
+	binary.BigEndian.PutUint64(k0[0:], uint64(t0.Unix()))
+	k0[8] = ':'
+	binary.BigEndian.PutUint64(k0[9:], uint64(t1.Unix()))
+
+	if SpanKeyVersion(k0) != SpanKeyV1 {
+		t.Fatalf("expected legacy key version, found: %v", SpanKeyVersion(k0))
+	}
+	s0, err := NewSpanFromKey(k0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s0.Equal(NewSpan(t0, t1)) {
+		t.Fatalf("expected spans to be equal: %v != %v", s0, NewSpan(t0, t1))
+	}
+}
+
+func TestSpanKeyKind(t *testing.T) {
+	s0 := NewOrdinalSpan(4, 9).Close()
+	k0, err := s0.Key()
+	if err != nil {
+		t.Fatal(err)
+	}
+	s1, err := NewSpanFromKey(k0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s0.Equal(s1) || s1.Kind() != Closed {
+		t.Fatalf("expected spans to be equal: %v != %v", s0, s1)
+	}
+	if s1.Overlap(NewSpan(s1.Start(), s1.End())) {
+		t.Fatalf("expected ordinal and time spans to not overlap")
+	}
+}
+
+func TestSpanKeyNegative(t *testing.T) {
+	// Pre-1970 spans, and the universe of the all
+	// window, have negative starts.
+	spans := []Span{
+		NewSpan(time.Date(1, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(292277026, 1, 1, 0, 0, 0, 0, time.UTC)),
+		NewSpan(time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC), time.Date(1969, 7, 20, 20, 18, 0, 0, time.UTC)),
+		NewSpan(time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(1970, 1, 1, 0, 1, 0, 0, time.UTC)),
+		NewSpan(time.Date(2017, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2017, 1, 1, 0, 1, 0, 0, time.UTC)),
+	}
+
+	var prev []byte
+	for _, s0 := range spans {
+		k0, err := s0.Key()
+		if err != nil {
+			t.Fatal(err)
+		}
+		s1, err := NewSpanFromKey(k0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !s0.Equal(s1) {
+			t.Fatalf("expected spans to be equal: %v != %v", s0, s1)
+		}
+		// Keys sort in order of span start.
+		if prev != nil && bytes.Compare(prev, k0) >= 0 {
+			t.Fatalf("expected key of %v to sort after the previous key", s0)
+		}
+		prev = k0
+	}
+}
+
+func TestSpanHalfOpen(t *testing.T) {
+	t0 := time.Date(2017, 1, 1, 10, 13, 0, 0, time.UTC)
+	s := NewSpan(t0, t0.Add(10*time.Minute))
+
+	if !s.Contains(t0) {
+		t.Fatal("expected span to contain its start")
+	}
+	if s.Contains(t0.Add(10 * time.Minute)) {
+		t.Fatal("expected span to not contain its end")
+	}
+	if s.Duration() != 10*time.Minute {
+		t.Fatalf("expected duration of 10m, found: %v", s.Duration())
+	}
+
+	touching := NewSpan(t0.Add(10*time.Minute), t0.Add(20*time.Minute))
+	if s.Overlap(touching) || touching.Overlap(s) {
+		t.Fatal("expected touching spans to not overlap")
+	}
+	inside := NewSpan(t0.Add(time.Minute), t0.Add(2*time.Minute))
+	if !s.Overlap(inside) || !inside.Overlap(s) {
+		t.Fatal("expected nested spans to overlap")
+	}
+}
@@ -6,16 +6,71 @@ import (
 	"github.com/lytics/flo/merger"
 )
 
-// State of a key's windows and associated values.
+// State of a key's windows and associated values. The
+// state may be read lazily, so windows should prefer
+// Get and Overlapping to Windows, which reads all
+// windows of the key.
 type State interface {
 	Del(Span)
 	Get(Span) []interface{}
 	Set(Span, []interface{})
+	Overlapping(Span) map[Span][]interface{}
 	Windows() map[Span][]interface{}
 }
 
-// Window strategy.
+// Window strategy. Apply is called when events are
+// mapped, with the event time and data, and returns the
+// spans of the event. Merge is called once for each of those
+// spans when the event is reduced, with the state of
+// the event's key, and must merge the value v into the
+// state using f, which merges new values, its first
+// argument, with existing ones, its second. Merge may
+// store the value under a different span than s, and
+// delete or replace other spans of the state.
+//
+// Most windows only need to define Apply, see Assign
+// and Merging.
 type Window interface {
-	Apply(ts time.Time) []Span
+	Apply(ts time.Time, v interface{}) []Span
 	Merge(s Span, v interface{}, prev State, f merger.ManyMerger) error
 }
+
+// Resetter is implemented by windows which reset the
+// state of a key on their own, rather than by having
+// every window of the key deleted.
+type Resetter interface {
+	Reset(prev State)
+}
+
+// Holder is implemented by windows which choose the span
+// of an event in Merge, rather than in Apply. Holding
+// returns the stored windows holding the event of span s
+// once it has been merged.
+type Holder interface {
+	Holding(s Span, prev State) map[Span][]interface{}
+}
+
+// Assembler is implemented by windows which store values
+// in a different form than they are emitted, for example
+// in panes. Assemble is given the stored windows of a
+// key and returns the windows to emit. Covering returns
+// the windows to emit which hold the stored span s.
+type Assembler interface {
+	Assemble(stored map[Span][]interface{}, f merger.ManyMerger) (map[Span][]interface{}, error)
+	Covering(s Span) []Span
+}
+
+// Windows create the window of each graph instance, for
+// windows which keep state, or are configured by the
+// instance's conf.
+type Windows interface {
+	Setup(graphType, graphName string, conf []byte) (Window, error)
+}
+
+// SetupFunc creates the window of a graph instance.
+type SetupFunc func(graphType, graphName string, conf []byte) (Window, error)
+
+// Setup the window by calling f.
+func (f SetupFunc) Setup(graphType, graphName string, conf []byte) (Window, error) {
+	return f(graphType, graphName, conf)
+}
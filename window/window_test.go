@@ -7,7 +7,7 @@ import (
 
 func newState() *state {
 	return &state{
-		Windows: map[Span][]interface{}{},
+		windows: map[Span][]interface{}{},
 	}
 }
 
@@ -15,24 +15,42 @@ func newState() *state {
 type state struct {
 	mu       sync.Mutex
 	dataType string
-	Windows  map[Span][]interface{}
+	windows  map[Span][]interface{}
 }
 
 func (s *state) Del(k Span) {
-	delete(s.Windows, k)
+	delete(s.windows, k)
 }
 
 func (s *state) Get(k Span) []interface{} {
-	return s.Windows[k]
+	return s.windows[k]
 }
 
 func (s *state) Set(k Span, v []interface{}) {
-	s.Windows[k] = v
+	s.windows[k] = v
+}
+
+func (s *state) Overlapping(r Span) map[Span][]interface{} {
+	ss := map[Span][]interface{}{}
+	for k, v := range s.windows {
+		if k.Overlap(r) {
+			ss[k] = v
+		}
+	}
+	return ss
+}
+
+func (s *state) Windows() map[Span][]interface{} {
+	ss := map[Span][]interface{}{}
+	for k, v := range s.windows {
+		ss[k] = v
+	}
+	return ss
 }
 
 func (s *state) Spans() []Span {
-	ss := make([]Span, 0, len(s.Windows))
-	for k := range s.Windows {
+	ss := make([]Span, 0, len(s.windows))
+	for k := range s.windows {
 		ss = append(ss, k)
 	}
 	return ss
@@ -40,12 +58,24 @@ func (s *state) Spans() []Span {
 
 func (s *state) Snapshot() *state {
 	n := newState()
-	for k, v := range s.Windows {
-		n.Windows[k] = v
+	for k, v := range s.windows {
+		n.windows[k] = v
 	}
 	return n
 }
 
+// merge the value v, with event time ts, into the
+// state ss, for each window w applies to ts.
+func merge(w Window, ts time.Time, v interface{}, ss State) error {
+	for _, s := range w.Apply(ts, v) {
+		err := w.Merge(s, v, ss, appendMerge)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func appendMerge(a, b []interface{}) ([]interface{}, error) {
 	c := append(a, b...)
 	return c, nil
@@ -0,0 +1,219 @@
+// Package windowtest checks custom windows against the
+// invariants the framework relies on.
+package windowtest
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/merger"
+	"github.com/lytics/flo/window"
+)
+
+// Check the window with events at the given times, the
+// value of each event is its index in times:
+//
+//   - Apply is deterministic and returns at least one span.
+//   - Time spans from Apply have a positive width and
+//     contain the event time.
+//   - After merging all events in order, every value is in
+//     some window, as emitted.
+func Check(t testing.TB, w window.Window, times []time.Time) {
+	t.Helper()
+
+	for i, ts := range times {
+		ss := w.Apply(ts, i)
+		if len(ss) == 0 {
+			t.Fatalf("windowtest: no spans for time: %v", ts)
+		}
+		if !reflect.DeepEqual(ss, w.Apply(ts, i)) {
+			t.Fatalf("windowtest: spans for time: %v not deterministic", ts)
+		}
+		for _, s := range ss {
+			if s.Kind() != window.Time {
+				continue
+			}
+			if s.Duration() <= 0 {
+				t.Fatalf("windowtest: span: %v for time: %v has no width", s, ts)
+			}
+			if !s.Contains(ts) {
+				t.Fatalf("windowtest: span: %v does not contain time: %v", s, ts)
+			}
+		}
+	}
+
+	state, err := Merge(w, times)
+	if err != nil {
+		t.Fatalf("windowtest: failed to merge: %v", err)
+	}
+	emitted, err := emit(w, state)
+	if err != nil {
+		t.Fatalf("windowtest: failed to assemble: %v", err)
+	}
+	found := map[int]bool{}
+	for _, vs := range emitted {
+		for _, v := range vs {
+			found[v.(int)] = true
+		}
+	}
+	for i, ts := range times {
+		if !found[i] {
+			t.Fatalf("windowtest: value of time: %v not in any window", ts)
+		}
+	}
+}
+
+// CheckMerging window, in addition to the checks done
+// by Check:
+//
+//   - Stored windows never overlap.
+//   - Merging the events in reverse order results in
+//     the same windows and values.
+func CheckMerging(t testing.TB, w window.Window, times []time.Time) {
+	t.Helper()
+
+	Check(t, w, times)
+
+	state, err := Merge(w, times)
+	if err != nil {
+		t.Fatalf("windowtest: failed to merge: %v", err)
+	}
+	stored := state.Windows()
+	for s0 := range stored {
+		for s1 := range stored {
+			if s0 != s1 && s0.Overlap(s1) {
+				t.Fatalf("windowtest: stored windows overlap: %v and %v", s0, s1)
+			}
+		}
+	}
+
+	reversed := make([]time.Time, len(times))
+	for i, ts := range times {
+		reversed[len(times)-1-i] = ts
+	}
+	rstate := NewState()
+	for i := range reversed {
+		err := merge(w, reversed[i], len(times)-1-i, rstate)
+		if err != nil {
+			t.Fatalf("windowtest: failed to merge: %v", err)
+		}
+	}
+	if !same(stored, rstate.Windows()) {
+		t.Fatalf("windowtest: windows depend on event order: %v != %v", stored, rstate.Windows())
+	}
+}
+
+// Merge events at the given times into a new state, the
+// value of each event is its index in times.
+func Merge(w window.Window, times []time.Time) (*State, error) {
+	state := NewState()
+	for i, ts := range times {
+		err := merge(w, ts, i, state)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return state, nil
+}
+
+func merge(w window.Window, ts time.Time, v interface{}, state window.State) error {
+	for _, s := range w.Apply(ts, v) {
+		err := w.Merge(s, v, state, merger.Cons())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func emit(w window.Window, state *State) (map[window.Span][]interface{}, error) {
+	if a, ok := w.(window.Assembler); ok {
+		return a.Assemble(state.Windows(), merger.Cons())
+	}
+	return state.Windows(), nil
+}
+
+// same windows with the same values, in any order.
+func same(a, b map[window.Span][]interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for s, vs := range a {
+		ws, ok := b[s]
+		if !ok || fmt.Sprint(sorted(vs)) != fmt.Sprint(sorted(ws)) {
+			return false
+		}
+	}
+	return true
+}
+
+func sorted(vs []interface{}) []int {
+	is := make([]int, 0, len(vs))
+	for _, v := range vs {
+		is = append(is, v.(int))
+	}
+	sort.Ints(is)
+	return is
+}
+
+// NewState in memory.
+func NewState() *State {
+	return &State{
+		windows: map[window.Span][]interface{}{},
+	}
+}
+
+// State in memory, for testing windows.
+type State struct {
+	mu      sync.Mutex
+	windows map[window.Span][]interface{}
+}
+
+// Del span.
+func (s *State) Del(k window.Span) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.windows, k)
+}
+
+// Get span.
+func (s *State) Get(k window.Span) []interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.windows[k]
+}
+
+// Set span.
+func (s *State) Set(k window.Span, vs []interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.windows[k] = vs
+}
+
+// Overlapping spans of k.
+func (s *State) Overlapping(k window.Span) map[window.Span][]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ws := map[window.Span][]interface{}{}
+	for s0, vs := range s.windows {
+		if s0.Overlap(k) {
+			ws[s0] = vs
+		}
+	}
+	return ws
+}
+
+// Windows of the state.
+func (s *State) Windows() map[window.Span][]interface{} {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	ws := map[window.Span][]interface{}{}
+	for s0, vs := range s.windows {
+		ws[s0] = vs
+	}
+	return ws
+}
@@ -0,0 +1,50 @@
+package windowtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/lytics/flo/window"
+)
+
+var times = []time.Time{
+	time.Date(2017, 02, 17, 13, 1, 0, 0, time.UTC),
+	time.Date(2017, 02, 17, 13, 2, 0, 0, time.UTC),
+	time.Date(2017, 02, 17, 15, 3, 0, 0, time.UTC),
+	time.Date(2017, 02, 18, 15, 4, 0, 0, time.UTC),
+	time.Date(2017, 02, 18, 15, 5, 0, 0, time.UTC),
+}
+
+func TestBuiltinWindows(t *testing.T) {
+	Check(t, window.All(), times)
+	Check(t, window.Fixed(time.Hour), times)
+	Check(t, window.Sliding(time.Hour, 10*time.Minute), times)
+	Check(t, window.Daily(nil), times)
+	Check(t, window.Count(2), times)
+	CheckMerging(t, window.Session(30*time.Minute), times)
+}
+
+func TestAssign(t *testing.T) {
+	// Half hour windows, assigned only.
+	halves := window.Assign(func(ts time.Time, v interface{}) []window.Span {
+		t0 := ts.Truncate(30 * time.Minute)
+		return []window.Span{window.NewSpan(t0, t0.Add(30*time.Minute))}
+	})
+	CheckMerging(t, halves, times)
+}
+
+func TestMerging(t *testing.T) {
+	// Sessions with a gap of an hour.
+	sessions := window.Merging(func(ts time.Time, v interface{}) []window.Span {
+		return []window.Span{window.NewSpan(ts, ts.Add(time.Hour))}
+	})
+	CheckMerging(t, sessions, times)
+
+	state, err := Merge(sessions, times)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(state.Windows()) != 3 {
+		t.Fatalf("expected 3 sessions, found: %v", state.Windows())
+	}
+}